@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"log/slog"
 
@@ -11,6 +12,7 @@ import (
 	_ "github.com/FreitasGabriel/fullcycle-api/docs"
 	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
 	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/storage"
 	"github.com/FreitasGabriel/fullcycle-api/internal/infra/webserver/handler"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
@@ -52,15 +54,68 @@ func main() {
 	}
 
 	logger.Info("Running migrations")
-	err = db.AutoMigrate(&entity.User{}, &entity.Product{})
+	err = db.AutoMigrate(&entity.User{}, &entity.Product{}, &entity.ProductHistory{}, &entity.ExchangeRate{}, &entity.Category{}, &entity.Tag{},
+		&entity.StockLevel{}, &entity.StockMovement{}, &entity.Reservation{}, &entity.Variant{}, &entity.ProductImage{}, &entity.ImportJob{}, &entity.PriceSchedule{},
+		&entity.Organization{}, &entity.Membership{}, &entity.Order{}, &entity.OrderItem{},
+		&entity.CartItem{}, &entity.Quote{}, &entity.QuoteItem{}, &entity.Review{}, &entity.SlugRedirect{})
 	if err != nil {
 		panic(err)
 	}
 
-	productDB := database.NewProduct(db)
+	imageStorage, err := storage.NewLocal(config.ImageStorageDir)
+	if err != nil {
+		panic(err)
+	}
+	productDB := database.NewProduct(db).WithBlobStorage(imageStorage)
 	userDB := database.NewUser(db)
-	productHandler := handler.NewProductHandler(productDB)
-	userHandler := handler.NewUserHandler(userDB)
+	organizationDB := database.NewOrganization(db)
+	exchangeRateDB := database.NewExchangeRate(db)
+	categoryDB := database.NewCategory(db)
+	stockDB := database.NewStock(db)
+	variantDB := database.NewVariant(db)
+	imageDB := database.NewProductImage(db)
+	importJobDB := database.NewImportJob(db)
+	priceScheduleDB := database.NewPriceSchedule(db)
+	orderDB := database.NewOrder(db)
+	cartDB := database.NewCart(db)
+	reviewDB := database.NewReview(db)
+	productHandler := handler.NewProductHandler(productDB, exchangeRateDB, variantDB, priceScheduleDB, config.MaxPageSize)
+	userHandler := handler.NewUserHandler(userDB, organizationDB)
+	organizationHandler := handler.NewOrganizationHandler(organizationDB, userDB)
+	exchangeRateHandler := handler.NewExchangeRateHandler(exchangeRateDB)
+	categoryHandler := handler.NewCategoryHandler(categoryDB)
+	inventoryHandler := handler.NewInventoryHandler(stockDB, productDB, config.ReservationTTL)
+	variantHandler := handler.NewVariantHandler(variantDB, productDB)
+	imageHandler := handler.NewImageHandler(imageDB, productDB, imageStorage, config.MaxImageSize)
+	priceScheduleHandler := handler.NewPriceScheduleHandler(productDB, priceScheduleDB)
+	orderHandler := handler.NewOrderHandler(orderDB, productDB, priceScheduleDB, config.MaxPageSize)
+	reviewHandler := handler.NewReviewHandler(reviewDB)
+	cartHandler := handler.NewCartHandler(cartDB, productDB, priceScheduleDB)
+	importHandler := handler.NewImportHandler(productDB, categoryDB, importJobDB, config.ImportSyncRows)
+
+	if _, err := importJobDB.FailUnfinished("server restarted before the import finished", time.Now()); err != nil {
+		panic(err)
+	}
+	if generated, err := productDB.GenerateMissingSlugs(); err != nil {
+		panic(err)
+	} else if generated > 0 {
+		logger.Info("Generated missing product slugs", "count", generated)
+	}
+	if backfilled, err := productDB.BackfillPublishedAt(); err != nil {
+		panic(err)
+	} else if backfilled > 0 {
+		logger.Info("Backfilled product publication times", "count", backfilled)
+	}
+	if backfilled, err := variantDB.BackfillTenants(); err != nil {
+		panic(err)
+	} else if backfilled > 0 {
+		logger.Info("Backfilled variant tenants", "count", backfilled)
+	}
+
+	if config.TrashRetentionDays > 0 {
+		go purgeTrash(productDB.WithActor("system"), config.TrashRetentionDays, logger)
+	}
+	go expireReservations(stockDB, logger)
 
 	logger.Info("Starting server")
 	r := chi.NewRouter()
@@ -75,9 +130,101 @@ func main() {
 		r.Use(jwtauth.Authenticator)
 		r.Post("/", productHandler.CreateProduct)
 		r.Get("/", productHandler.GetAllProducts)
+		r.Post("/batch", productHandler.BatchProducts)
+		r.Get("/export", productHandler.ExportProducts)
+		r.Post("/import", importHandler.ImportProducts)
+		r.Get("/import/{jobID}", importHandler.GetImportJob)
+		r.Get("/trash", productHandler.GetDeletedProducts)
+		r.With(handler.AdminOnly).Delete("/trash/{id}", productHandler.PurgeProduct)
+		r.Get("/by-slug/{slug}", productHandler.GetProductBySlug)
+		r.Get("/by-sku/{sku}", productHandler.GetProductBySKU)
 		r.Get("/{id}", productHandler.GetProduct)
 		r.Put("/{id}", productHandler.UpdateProduct)
+		r.Patch("/{id}", productHandler.PatchProduct)
 		r.Delete("/{id}", productHandler.DeleteProduct)
+		r.Post("/{id}/restore", productHandler.RestoreProduct)
+		r.Post("/{id}/publish", productHandler.PublishProduct)
+		r.Post("/{id}/unpublish", productHandler.UnpublishProduct)
+		r.Post("/{id}/archive", productHandler.ArchiveProduct)
+		r.Get("/{id}/history", productHandler.GetProductHistory)
+		// Sub-resources are stored apart from products, so the tenant of
+		// their product is checked before reaching them.
+		r.Group(func(r chi.Router) {
+			r.Use(handler.ProductInTenant(productDB))
+			r.Get("/{id}/variants", variantHandler.GetVariants)
+			r.Post("/{id}/variants", variantHandler.CreateVariant)
+			r.Get("/{id}/variants/{variantID}", variantHandler.GetVariant)
+			r.Put("/{id}/variants/{variantID}", variantHandler.UpdateVariant)
+			r.Delete("/{id}/variants/{variantID}", variantHandler.DeleteVariant)
+			r.Get("/{id}/images", imageHandler.GetImages)
+			r.Post("/{id}/images", imageHandler.UploadImage)
+			r.Put("/{id}/images/order", imageHandler.ReorderImages)
+			r.Delete("/{id}/images/{imageID}", imageHandler.DeleteImage)
+			r.Post("/{id}/images/{imageID}/primary", imageHandler.SetPrimaryImage)
+			r.Get("/{id}/images/{imageID}/file", imageHandler.GetImageFile)
+			r.Get("/{id}/images/{imageID}/thumbnails/{size}", imageHandler.GetImageThumbnail)
+			r.Get("/{id}/prices", priceScheduleHandler.GetPriceTimeline)
+			r.Get("/{id}/price-schedules", priceScheduleHandler.GetPriceSchedules)
+			r.Post("/{id}/price-schedules", priceScheduleHandler.CreatePriceSchedule)
+			r.Delete("/{id}/price-schedules/{scheduleID}", priceScheduleHandler.DeletePriceSchedule)
+			r.Get("/{id}/reviews", reviewHandler.GetReviews)
+			r.Post("/{id}/reviews", reviewHandler.CreateReview)
+			r.Put("/{id}/reviews/{reviewID}", reviewHandler.UpdateReview)
+			r.Delete("/{id}/reviews/{reviewID}", reviewHandler.DeleteReview)
+			r.With(handler.AdminOnly).Put("/{id}/reviews/{reviewID}/status", reviewHandler.ModerateReview)
+			r.Get("/{id}/stock", inventoryHandler.GetStock)
+			r.Get("/{id}/stock/adjustments", inventoryHandler.GetStockAdjustments)
+			r.Post("/{id}/stock/adjustments", inventoryHandler.AdjustStock)
+			r.Post("/{id}/reservations", inventoryHandler.CreateReservation)
+			r.Delete("/{id}/reservations/{reservationID}", inventoryHandler.ReleaseReservation)
+		})
+	})
+
+	r.Route("/categories", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(config.TokenAuthKey))
+		r.Use(jwtauth.Authenticator)
+		r.Post("/", categoryHandler.CreateCategory)
+		r.Get("/", categoryHandler.GetCategories)
+		r.Get("/{id}", categoryHandler.GetCategory)
+		r.Put("/{id}", categoryHandler.UpdateCategory)
+		r.Delete("/{id}", categoryHandler.DeleteCategory)
+		r.Get("/{id}/breadcrumb", categoryHandler.GetCategoryBreadcrumb)
+	})
+
+	r.Route("/orders", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(config.TokenAuthKey))
+		r.Use(jwtauth.Authenticator)
+		r.Post("/", orderHandler.CreateOrder)
+		r.Get("/", orderHandler.GetOrders)
+		r.With(handler.AdminOnly).Get("/all", orderHandler.GetAllOrders)
+		r.Get("/{id}", orderHandler.GetOrder)
+		r.Put("/{id}/status", orderHandler.UpdateOrderStatus)
+	})
+
+	r.Route("/cart", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(config.TokenAuthKey))
+		r.Use(jwtauth.Authenticator)
+		r.Get("/", cartHandler.GetCart)
+		r.Post("/items", cartHandler.AddCartItem)
+		r.Put("/items/{productID}", cartHandler.UpdateCartItem)
+		r.Delete("/items/{productID}", cartHandler.DeleteCartItem)
+		r.Post("/checkout", cartHandler.Checkout)
+		r.Get("/quotes/{id}", cartHandler.GetQuote)
+	})
+
+	r.Route("/organizations", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(config.TokenAuthKey))
+		r.Use(jwtauth.Authenticator)
+		r.Get("/", organizationHandler.GetOrganizations)
+		r.With(handler.AdminOnly).Post("/", organizationHandler.CreateOrganization)
+		r.With(handler.AdminOnly).Post("/{id}/members", organizationHandler.AddMember)
+	})
+
+	r.Route("/exchange-rates", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(config.TokenAuthKey))
+		r.Use(jwtauth.Authenticator)
+		r.Get("/", exchangeRateHandler.GetExchangeRates)
+		r.With(handler.AdminOnly).Post("/", exchangeRateHandler.UploadExchangeRates)
 	})
 
 	r.Route(("/user"), func(r chi.Router) {
@@ -90,6 +237,35 @@ func main() {
 	http.ListenAndServe(":8000", r)
 }
 
+// purgeTrash permanently removes products that have been in the trash for
+// longer than retentionDays, checking once an hour.
+func purgeTrash(productDB database.ProductInterface, retentionDays int, logger *slog.Logger) {
+	for {
+		purged, err := productDB.PurgeDeletedBefore(time.Now().AddDate(0, 0, -retentionDays))
+		if err != nil {
+			logger.Error("Purging trashed products", "error", err)
+		} else if purged > 0 {
+			logger.Info("Purged trashed products", "count", purged)
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+// expireReservations gives the units of expired reservations back. Stock
+// reads and writes also do it for the product they touch, so this only keeps
+// idle products accurate.
+func expireReservations(stockDB database.StockInterface, logger *slog.Logger) {
+	for {
+		expired, err := stockDB.ExpireReservations(time.Now())
+		if err != nil {
+			logger.Error("Expiring stock reservations", "error", err)
+		} else if expired > 0 {
+			logger.Info("Expired stock reservations", "count", expired)
+		}
+		time.Sleep(time.Minute)
+	}
+}
+
 func LogRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.Println(r.Method, r.URL.Path)
@@ -6,16 +6,22 @@ import (
 )
 
 type conf struct {
-	DBDriver      string `mapstructure:"DB_DRIVER"`
-	DBHost        string `mapstructure:"DB_HOST"`
-	DBPort        string `mapstructure:"DB_PORT"`
-	DBUser        string `mapstructure:"DB_USER"`
-	DBPassword    string `mapstructure:"DB_PASSWORD"`
-	DBName        string `mapstructure:"DB_NAME"`
-	WebServerPort string `mapstructure:"WEB_SERVER_PORT"`
-	JWTSecret     string `mapstructure:"JWT_SECRET"`
-	JWTExpiresIn  int    `mapstructure:"JWT_EXPIRESIN"`
-	TokenAuthKey  *jwtauth.JWTAuth
+	DBDriver           string `mapstructure:"DB_DRIVER"`
+	DBHost             string `mapstructure:"DB_HOST"`
+	DBPort             string `mapstructure:"DB_PORT"`
+	DBUser             string `mapstructure:"DB_USER"`
+	DBPassword         string `mapstructure:"DB_PASSWORD"`
+	DBName             string `mapstructure:"DB_NAME"`
+	WebServerPort      string `mapstructure:"WEB_SERVER_PORT"`
+	JWTSecret          string `mapstructure:"JWT_SECRET"`
+	JWTExpiresIn       int    `mapstructure:"JWT_EXPIRESIN"`
+	MaxPageSize        int    `mapstructure:"MAX_PAGE_SIZE"`
+	TrashRetentionDays int    `mapstructure:"TRASH_RETENTION_DAYS"`
+	ReservationTTL     int    `mapstructure:"RESERVATION_TTL_SECONDS"`
+	ImageStorageDir    string `mapstructure:"IMAGE_STORAGE_DIR"`
+	MaxImageSize       int64  `mapstructure:"MAX_IMAGE_SIZE"`
+	ImportSyncRows     int    `mapstructure:"IMPORT_SYNC_ROWS"`
+	TokenAuthKey       *jwtauth.JWTAuth
 }
 
 func LoadConfig(path string) (*conf, error) {
@@ -1,19 +1,171 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+// CreateProductInput creates a product. The slug is generated from the name
+// when omitted.
 type CreateProductInput struct {
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
+	Name       string       `json:"name"`
+	Price      entity.Money `json:"price"`
+	CategoryID *entity.ID   `json:"category_id"`
+	Tags       []string     `json:"tags"`
+	Slug       string       `json:"slug"`
+	SKU        *string      `json:"sku"`
+}
+
+// BatchOperationInput is one operation of a product batch. Create needs
+// product; update needs id, version and product; delete needs id and
+// version. version plays the role of If-Match on the single product routes.
+type BatchOperationInput struct {
+	Op      string              `json:"op" enums:"create,update,delete"`
+	ID      *entity.ID          `json:"id,omitempty"`
+	Version *int                `json:"version,omitempty"`
+	Product *CreateProductInput `json:"product,omitempty"`
+}
+
+type BatchInput struct {
+	Operations      []BatchOperationInput `json:"operations"`
+	ContinueOnError bool                  `json:"continue_on_error"`
+}
+
+// BatchOperationResult reports the outcome of an operation with the status
+// code the single product route would have answered.
+type BatchOperationResult struct {
+	Index   int        `json:"index"`
+	Op      string     `json:"op"`
+	Status  int        `json:"status"`
+	ID      *entity.ID `json:"id,omitempty"`
+	Version int        `json:"version,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+// BatchOutput reports a batch. Failed counts the operations that were not
+// applied, so a committed batch with failures is told apart from a batch
+// that fully succeeded.
+type BatchOutput struct {
+	Committed bool                   `json:"committed"`
+	Failed    int                    `json:"failed"`
+	Results   []BatchOperationResult `json:"results"`
+}
+
+// ImportProductInput is one row of a product import. Rows with an id update
+// that product, the others create a new one.
+type ImportProductInput struct {
+	ID         *entity.ID   `json:"id"`
+	Name       string       `json:"name"`
+	Price      entity.Money `json:"price"`
+	CategoryID *entity.ID   `json:"category_id"`
+	Tags       []string     `json:"tags"`
 }
 
 type CreateProductOutput struct {
-	ID    string  `json:"id"`
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
+	ID    string       `json:"id"`
+	Name  string       `json:"name"`
+	Price entity.Money `json:"price"`
 }
 
+// UpdateProductInput replaces a product. An omitted slug keeps the current
+// one and an omitted sku removes it.
 type UpdateProductInput struct {
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
+	Name       string       `json:"name"`
+	Price      entity.Money `json:"price"`
+	CategoryID *entity.ID   `json:"category_id"`
+	Tags       []string     `json:"tags"`
+	Slug       string       `json:"slug"`
+	SKU        *string      `json:"sku"`
+}
+
+type ConvertedPriceOutput struct {
+	Price    entity.Money `json:"price"`
+	Rate     string       `json:"rate"`
+	RateDate *time.Time   `json:"rate_date,omitempty"`
+}
+
+// PriceScheduleInput schedules a price from effective_from until
+// effective_to, or for good when effective_to is omitted.
+type PriceScheduleInput struct {
+	Price         entity.Money `json:"price"`
+	EffectiveFrom time.Time    `json:"effective_from"`
+	EffectiveTo   *time.Time   `json:"effective_to"`
+}
+
+type ReviewInput struct {
+	Stars int    `json:"stars" minimum:"1" maximum:"5"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type ReviewStatusInput struct {
+	Status string `json:"status" enums:"pending,approved,rejected"`
+}
+
+type CategoryInput struct {
+	Name     string     `json:"name"`
+	ParentID *entity.ID `json:"parent_id"`
+}
+
+type VariantInput struct {
+	SKU     string            `json:"sku" example:"SHIRT-M-BLUE"`
+	Price   *entity.Money     `json:"price"`
+	Options map[string]string `json:"options"`
+}
+
+type ImageOrderInput struct {
+	IDs []string `json:"ids"`
+}
+
+type StockAdjustmentInput struct {
+	Kind     string `json:"kind" example:"receive"`
+	Quantity int    `json:"quantity" example:"10"`
+	Reason   string `json:"reason"`
+}
+
+type StockLevelOutput struct {
+	ProductID string `json:"product_id"`
+	OnHand    int    `json:"on_hand"`
+	Reserved  int    `json:"reserved"`
+	Available int    `json:"available"`
+}
+
+type ReservationInput struct {
+	Quantity   int `json:"quantity" example:"1"`
+	TTLSeconds int `json:"ttl_seconds,omitempty" example:"900"`
+}
+
+type ExchangeRateInput struct {
+	From string `json:"from" example:"USD"`
+	To   string `json:"to" example:"BRL"`
+	Rate string `json:"rate" example:"5.4321"`
+	Date string `json:"date" example:"2024-05-01"`
+}
+
+type OrderItemInput struct {
+	ProductID entity.ID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+// CreateOrderInput lists the products to buy. Lines for the same product are
+// merged.
+type CreateOrderInput struct {
+	Items []OrderItemInput `json:"items"`
+}
+
+// CartItemInput adds Quantity units of a product to the cart.
+type CartItemInput struct {
+	ProductID entity.ID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+type CartQuantityInput struct {
+	Quantity int `json:"quantity"`
+}
+
+type OrderStatusInput struct {
+	Status string `json:"status" enums:"paid,shipped,delivered,cancelled,refunded"`
 }
 
 type CreateUserInput struct {
@@ -22,13 +174,25 @@ type CreateUserInput struct {
 	Password string `json:"password"`
 }
 
+// LoginInput signs a user in. OrganizationID picks the organization the
+// token is for and is only required for members of several organizations.
 type LoginInput struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email          string     `json:"email"`
+	Password       string     `json:"password"`
+	OrganizationID *entity.ID `json:"organization_id"`
 }
 
 type GetJWTOutput struct {
-	AccessToken string `json:"access_token"`
+	AccessToken    string     `json:"access_token"`
+	OrganizationID *entity.ID `json:"organization_id,omitempty"`
+}
+
+type OrganizationInput struct {
+	Name string `json:"name"`
+}
+
+type MemberInput struct {
+	Email string `json:"email"`
 }
 
 type ErrorResponse struct {
@@ -0,0 +1,11 @@
+package dto
+
+import "github.com/FreitasGabriel/fullcycle-api/internal/entity"
+
+type OrderListOutput struct {
+	Items   []*entity.Order `json:"items"`
+	Page    int             `json:"page"`
+	Limit   int             `json:"limit"`
+	Total   int64           `json:"total"`
+	HasNext bool            `json:"has_next"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+import "github.com/FreitasGabriel/fullcycle-api/internal/entity"
+
+// ProductOutput is a product as returned by the API. EffectivePrice is what
+// the product sells for when the response is built, which differs from
+// Price while a price schedule applies, and ConvertedPrice converts it.
+type ProductOutput struct {
+	*entity.Product
+	EffectivePrice entity.EffectivePrice `json:"effective_price"`
+	ConvertedPrice *ConvertedPriceOutput `json:"converted_price,omitempty"`
+	Variants       []*entity.Variant     `json:"variants,omitempty"`
+}
+
+type ProductListOutput struct {
+	Items      []*ProductOutput `json:"items"`
+	Page       int              `json:"page,omitempty"`
+	Limit      int              `json:"limit"`
+	Total      int64            `json:"total"`
+	HasNext    bool             `json:"has_next"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+type ProductImageOutput struct {
+	*entity.ProductImage
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails"`
+}
@@ -0,0 +1,169 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+var (
+	ErrCartIsEmpty     = errors.New("cart is empty")
+	ErrCartUnavailable = errors.New("cart holds products that are no longer available")
+	ErrCartCurrencies  = errors.New("cart items must be priced in one currency")
+)
+
+// CartItem is a product in the cart of a user. AddedPrice is the effective
+// price of the product when it was added, kept to tell the user about price
+// changes before checkout.
+type CartItem struct {
+	TenantID   string       `json:"-" gorm:"primaryKey"`
+	UserID     string       `json:"-" gorm:"primaryKey"`
+	ProductID  entity.ID    `json:"product_id" gorm:"primaryKey"`
+	Quantity   int          `json:"quantity"`
+	AddedPrice entity.Money `json:"added_price" gorm:"embedded;embeddedPrefix:added_price_"`
+	AddedAt    time.Time    `json:"added_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+func NewCartItem(userID string, productID entity.ID, price entity.Money, quantity int) (*CartItem, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	now := time.Now()
+	return &CartItem{
+		UserID:     userID,
+		ProductID:  productID,
+		Quantity:   quantity,
+		AddedPrice: price,
+		AddedAt:    now,
+		UpdatedAt:  now,
+	}, nil
+}
+
+// CartLine is a cart item priced at the current price of its product.
+// Deleted lines have no price and Repriced lines cost something else than
+// when they were added.
+type CartLine struct {
+	ProductID  entity.ID     `json:"product_id"`
+	Name       string        `json:"name"`
+	Quantity   int           `json:"quantity"`
+	AddedPrice entity.Money  `json:"added_price"`
+	UnitPrice  *entity.Money `json:"unit_price,omitempty"`
+	Total      *entity.Money `json:"total,omitempty"`
+	Deleted    bool          `json:"deleted"`
+	Repriced   bool          `json:"repriced"`
+}
+
+// NewCartLine prices item at price, the current effective price of the
+// product named name. A nil price means the product was deleted or is no
+// longer for sale.
+func NewCartLine(item *CartItem, name string, price *entity.Money) (CartLine, error) {
+	line := CartLine{
+		ProductID:  item.ProductID,
+		Name:       name,
+		Quantity:   item.Quantity,
+		AddedPrice: item.AddedPrice,
+		Deleted:    price == nil,
+	}
+	if price == nil {
+		return line, nil
+	}
+	total, err := price.Times(int64(item.Quantity))
+	if err != nil {
+		return CartLine{}, err
+	}
+	line.UnitPrice = price
+	line.Total = &total
+	line.Repriced = *price != item.AddedPrice
+	return line, nil
+}
+
+// Cart is the priced view of the items of a user. Total adds up the lines
+// that are still available and is left out when they use more than one
+// currency.
+type Cart struct {
+	Items []CartLine    `json:"items"`
+	Total *entity.Money `json:"total,omitempty"`
+}
+
+func NewCart(lines []CartLine) (*Cart, error) {
+	cart := &Cart{Items: lines}
+	if cart.Items == nil {
+		cart.Items = []CartLine{}
+	}
+	for _, line := range lines {
+		if line.Total == nil {
+			continue
+		}
+		if cart.Total == nil {
+			cart.Total = &entity.Money{Currency: line.Total.Currency}
+		}
+		total, err := cart.Total.Add(*line.Total)
+		if errors.Is(err, entity.ErrCurrencyMismatch) {
+			cart.Total = nil
+			return cart, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		cart.Total = &total
+	}
+	return cart, nil
+}
+
+// QuoteItem is a line of a quote, numbered from 1 by Line.
+type QuoteItem struct {
+	ID        entity.ID    `json:"id"`
+	QuoteID   entity.ID    `json:"-" gorm:"index"`
+	Line      int          `json:"line"`
+	ProductID entity.ID    `json:"product_id" gorm:"index"`
+	Name      string       `json:"name"`
+	UnitPrice entity.Money `json:"unit_price" gorm:"embedded;embeddedPrefix:unit_price_"`
+	Quantity  int          `json:"quantity"`
+	Total     entity.Money `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+}
+
+// Quote is the priced snapshot of a cart taken at checkout. Quotes are
+// never changed once created.
+type Quote struct {
+	ID        entity.ID    `json:"id"`
+	UserID    string       `json:"user_id" gorm:"index"`
+	TenantID  string       `json:"-" gorm:"index"`
+	Items     []QuoteItem  `json:"items"`
+	Total     entity.Money `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// NewQuote snapshots cart for userID. Every line must still be available
+// and priced in the same currency.
+func NewQuote(userID string, cart *Cart) (*Quote, error) {
+	if len(cart.Items) == 0 {
+		return nil, ErrCartIsEmpty
+	}
+	quote := &Quote{
+		ID:        entity.NewId(),
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+	for i, line := range cart.Items {
+		if line.Deleted {
+			return nil, ErrCartUnavailable
+		}
+		quote.Items = append(quote.Items, QuoteItem{
+			ID:        entity.NewId(),
+			QuoteID:   quote.ID,
+			Line:      i + 1,
+			ProductID: line.ProductID,
+			Name:      line.Name,
+			UnitPrice: *line.UnitPrice,
+			Quantity:  line.Quantity,
+			Total:     *line.Total,
+		})
+	}
+	if cart.Total == nil {
+		return nil, ErrCartCurrencies
+	}
+	quote.Total = *cart.Total
+	return quote, nil
+}
@@ -0,0 +1,96 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCartItem(t *testing.T) {
+	productID := entity.NewId()
+	item, err := NewCartItem("user-1", productID, brl(1050), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1", item.UserID)
+	assert.Equal(t, productID, item.ProductID)
+	assert.Equal(t, brl(1050), item.AddedPrice)
+	assert.False(t, item.AddedAt.IsZero())
+
+	_, err = NewCartItem("user-1", productID, brl(1050), 0)
+	assert.Equal(t, ErrInvalidQuantity, err)
+}
+
+func TestNewCartLine(t *testing.T) {
+	item, _ := NewCartItem("user-1", entity.NewId(), brl(1000), 3)
+
+	price := brl(1000)
+	line, err := NewCartLine(item, "product 1", &price)
+	assert.Nil(t, err)
+	assert.Equal(t, brl(3000), *line.Total)
+	assert.False(t, line.Repriced)
+	assert.False(t, line.Deleted)
+
+	price = brl(900)
+	line, _ = NewCartLine(item, "product 1", &price)
+	assert.Equal(t, brl(2700), *line.Total)
+	assert.True(t, line.Repriced)
+
+	line, _ = NewCartLine(item, "product 1", nil)
+	assert.True(t, line.Deleted)
+	assert.Nil(t, line.UnitPrice)
+	assert.Nil(t, line.Total)
+}
+
+func TestNewCart(t *testing.T) {
+	first, _ := NewCartItem("user-1", entity.NewId(), brl(1000), 2)
+	second, _ := NewCartItem("user-1", entity.NewId(), brl(550), 1)
+	firstPrice, secondPrice := brl(1000), brl(550)
+	firstLine, _ := NewCartLine(first, "product 1", &firstPrice)
+	secondLine, _ := NewCartLine(second, "product 2", &secondPrice)
+	deletedLine, _ := NewCartLine(second, "product 3", nil)
+
+	cart, err := NewCart([]CartLine{firstLine, secondLine, deletedLine})
+	assert.Nil(t, err)
+	assert.Equal(t, brl(2550), *cart.Total)
+
+	dollars := entity.Money{Amount: 100, Currency: "USD"}
+	dollarLine, _ := NewCartLine(second, "product 4", &dollars)
+	cart, err = NewCart([]CartLine{firstLine, dollarLine})
+	assert.Nil(t, err)
+	assert.Nil(t, cart.Total)
+
+	cart, _ = NewCart(nil)
+	assert.Empty(t, cart.Items)
+	assert.Nil(t, cart.Total)
+}
+
+func TestNewQuote(t *testing.T) {
+	item, _ := NewCartItem("user-1", entity.NewId(), brl(1000), 2)
+	price := brl(1200)
+	line, _ := NewCartLine(item, "product 1", &price)
+	cart, _ := NewCart([]CartLine{line})
+
+	quote, err := NewQuote("user-1", cart)
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1", quote.UserID)
+	assert.Equal(t, brl(2400), quote.Total)
+	assert.Len(t, quote.Items, 1)
+	assert.Equal(t, quote.ID, quote.Items[0].QuoteID)
+	assert.Equal(t, 1, quote.Items[0].Line)
+	assert.Equal(t, brl(1200), quote.Items[0].UnitPrice)
+
+	empty, _ := NewCart(nil)
+	_, err = NewQuote("user-1", empty)
+	assert.Equal(t, ErrCartIsEmpty, err)
+
+	deleted, _ := NewCartLine(item, "product 1", nil)
+	cart, _ = NewCart([]CartLine{line, deleted})
+	_, err = NewQuote("user-1", cart)
+	assert.Equal(t, ErrCartUnavailable, err)
+
+	dollars := entity.Money{Amount: 100, Currency: "USD"}
+	dollarLine, _ := NewCartLine(item, "product 2", &dollars)
+	cart, _ = NewCart([]CartLine{line, dollarLine})
+	_, err = NewQuote("user-1", cart)
+	assert.Equal(t, ErrCartCurrencies, err)
+}
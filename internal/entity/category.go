@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+var ErrCategoryParentIsSelf = errors.New("category cannot be its own parent")
+
+// Category groups products in a tree. Root categories have no parent. Like
+// products, categories belong to a tenant and so do their parents.
+type Category struct {
+	ID        entity.ID  `json:"id"`
+	TenantID  string     `json:"-" gorm:"index"`
+	Name      string     `json:"name"`
+	ParentID  *entity.ID `json:"parent_id" gorm:"index"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func NewCategory(name string, parentID *entity.ID) (*Category, error) {
+	category := &Category{
+		ID:        entity.NewId(),
+		Name:      name,
+		ParentID:  parentID,
+		CreatedAt: time.Now(),
+	}
+	if err := category.Validate(); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+func (c *Category) Validate() error {
+	if c.ID.String() == "" {
+		return ErrIDIsRequired
+	}
+	if _, err := entity.ParseID(c.ID.String()); err != nil {
+		return ErrInvalidID
+	}
+	if c.Name == "" {
+		return ErrNameIsRequired
+	}
+	if c.ParentID != nil && *c.ParentID == c.ID {
+		return ErrCategoryParentIsSelf
+	}
+	return nil
+}
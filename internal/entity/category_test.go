@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCategory(t *testing.T) {
+	root, err := NewCategory("Electronics", nil)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, root.ID)
+	assert.Nil(t, root.ParentID)
+
+	child, err := NewCategory("Phones", &root.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, root.ID, *child.ParentID)
+}
+
+func TestCategoryWhenNameIsRequired(t *testing.T) {
+	c, err := NewCategory("", nil)
+	assert.Nil(t, c)
+	assert.Equal(t, ErrNameIsRequired, err)
+}
+
+func TestCategoryWhenParentIsSelf(t *testing.T) {
+	c, err := NewCategory("Phones", nil)
+	assert.Nil(t, err)
+
+	c.ParentID = &c.ID
+	assert.Equal(t, ErrCategoryParentIsSelf, c.Validate())
+}
@@ -0,0 +1,130 @@
+package entity
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+var (
+	ErrInvalidRate      = errors.New("rate must be a positive decimal")
+	ErrSameCurrency     = errors.New("rate currencies must differ")
+	ErrRateDateRequired = errors.New("rate date is required")
+	ErrCurrencyMismatch = errors.New("money currency does not match the rate")
+)
+
+// ExchangeRate says how many units of To one unit of From buys on Date. The
+// rate is kept as a decimal string so it is never rounded by a float.
+type ExchangeRate struct {
+	ID        entity.ID `json:"id"`
+	From      string    `json:"from" gorm:"column:from_currency;size:3;uniqueIndex:idx_exchange_rates_pair_date"`
+	To        string    `json:"to" gorm:"column:to_currency;size:3;uniqueIndex:idx_exchange_rates_pair_date"`
+	Rate      string    `json:"rate" example:"5.4321"`
+	Date      time.Time `json:"date" gorm:"column:rate_date;uniqueIndex:idx_exchange_rates_pair_date"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewExchangeRate(from, to, rate string, date time.Time) (*ExchangeRate, error) {
+	exchangeRate := &ExchangeRate{
+		ID:        entity.NewId(),
+		From:      from,
+		To:        to,
+		Rate:      rate,
+		Date:      date.UTC().Truncate(24 * time.Hour),
+		CreatedAt: time.Now(),
+	}
+	if err := exchangeRate.Validate(); err != nil {
+		return nil, err
+	}
+	return exchangeRate, nil
+}
+
+func (r *ExchangeRate) Validate() error {
+	if !entity.IsCurrency(r.From) || !entity.IsCurrency(r.To) {
+		return entity.ErrInvalidCurrency
+	}
+	if r.From == r.To {
+		return ErrSameCurrency
+	}
+	if _, err := r.rate(); err != nil {
+		return err
+	}
+	if r.Date.IsZero() {
+		return ErrRateDateRequired
+	}
+	return nil
+}
+
+// Inverse returns the rate for converting To back into From.
+func (r *ExchangeRate) Inverse() (*ExchangeRate, error) {
+	rate, err := r.rate()
+	if err != nil {
+		return nil, err
+	}
+	return &ExchangeRate{
+		ID:        r.ID,
+		From:      r.To,
+		To:        r.From,
+		Rate:      new(big.Rat).Inv(rate).FloatString(10),
+		Date:      r.Date,
+		CreatedAt: r.CreatedAt,
+	}, nil
+}
+
+// Convert turns m, which must be in From, into To. The result is rounded to
+// the minor unit of To with banker's rounding (half to even), so repeated
+// conversions do not drift in one direction.
+func (r *ExchangeRate) Convert(m entity.Money) (entity.Money, error) {
+	if m.Currency != r.From {
+		return entity.Money{}, ErrCurrencyMismatch
+	}
+	rate, err := r.rate()
+	if err != nil {
+		return entity.Money{}, err
+	}
+	fromExponent, err := entity.CurrencyExponent(r.From)
+	if err != nil {
+		return entity.Money{}, err
+	}
+	toExponent, err := entity.CurrencyExponent(r.To)
+	if err != nil {
+		return entity.Money{}, err
+	}
+
+	amount := new(big.Rat).SetInt64(m.Amount)
+	amount.Mul(amount, rate)
+	amount.Mul(amount, new(big.Rat).SetFrac(pow10(toExponent), pow10(fromExponent)))
+
+	return entity.Money{Amount: roundHalfEven(amount), Currency: r.To}, nil
+}
+
+func (r *ExchangeRate) rate() (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(r.Rate)
+	if !ok || rate.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+	return rate, nil
+}
+
+func roundHalfEven(x *big.Rat) int64 {
+	quotient, remainder := new(big.Int).QuoRem(x.Num(), x.Denom(), new(big.Int))
+	// Compare twice the remainder with the denominator to find out which
+	// side of the half the fraction is on.
+	twice := new(big.Int).Abs(remainder)
+	twice.Lsh(twice, 1)
+	switch twice.Cmp(x.Denom()) {
+	case 1:
+		quotient.Add(quotient, big.NewInt(int64(x.Sign())))
+	case 0:
+		if quotient.Bit(0) == 1 {
+			quotient.Add(quotient, big.NewInt(int64(x.Sign())))
+		}
+	}
+	return quotient.Int64()
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
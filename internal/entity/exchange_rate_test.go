@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewExchangeRate(t *testing.T) {
+	rate, err := NewExchangeRate("USD", "BRL", "5.25", time.Date(2024, 5, 1, 15, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, rate.ID)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), rate.Date)
+
+	_, err = NewExchangeRate("USD", "USD", "1", time.Now())
+	assert.Equal(t, ErrSameCurrency, err)
+
+	_, err = NewExchangeRate("USD", "BRL", "-1", time.Now())
+	assert.Equal(t, ErrInvalidRate, err)
+
+	_, err = NewExchangeRate("USD", "XYZ", "1", time.Now())
+	assert.Equal(t, entity.ErrInvalidCurrency, err)
+}
+
+func TestExchangeRateConvertRoundsHalfToEven(t *testing.T) {
+	rate, err := NewExchangeRate("BRL", "USD", "0.5", time.Now())
+	assert.Nil(t, err)
+
+	// 0.01 BRL is 0.005 USD, which rounds down to the even cent.
+	converted, err := rate.Convert(entity.Money{Amount: 1, Currency: "BRL"})
+	assert.Nil(t, err)
+	assert.Equal(t, entity.Money{Amount: 0, Currency: "USD"}, converted)
+
+	// 0.03 BRL is 0.015 USD, which rounds up to the even cent.
+	converted, err = rate.Convert(entity.Money{Amount: 3, Currency: "BRL"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), converted.Amount)
+
+	_, err = rate.Convert(entity.Money{Amount: 3, Currency: "EUR"})
+	assert.Equal(t, ErrCurrencyMismatch, err)
+}
+
+func TestExchangeRateConvertBetweenExponents(t *testing.T) {
+	rate, err := NewExchangeRate("USD", "JPY", "151.37", time.Now())
+	assert.Nil(t, err)
+
+	converted, err := rate.Convert(entity.Money{Amount: 1099, Currency: "USD"})
+	assert.Nil(t, err)
+	assert.Equal(t, entity.Money{Amount: 1664, Currency: "JPY"}, converted)
+
+	inverse, err := rate.Inverse()
+	assert.Nil(t, err)
+	assert.Equal(t, "JPY", inverse.From)
+	assert.Equal(t, "USD", inverse.To)
+}
+
+func TestProductConvertPrice(t *testing.T) {
+	p, err := NewProduct("product 1", brl(1000))
+	assert.Nil(t, err)
+	rate, err := NewExchangeRate("BRL", "USD", "0.2", time.Now())
+	assert.Nil(t, err)
+
+	converted, err := p.ConvertPrice(rate)
+	assert.Nil(t, err)
+	assert.Equal(t, entity.Money{Amount: 200, Currency: "USD"}, converted)
+}
@@ -0,0 +1,107 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+const (
+	ImportCSV    = "csv"
+	ImportNDJSON = "ndjson"
+)
+
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+const (
+	ImportRowCreated  = "created"
+	ImportRowUpdated  = "updated"
+	ImportRowRejected = "rejected"
+)
+
+var ErrInvalidImportFormat = errors.New("import format must be csv or ndjson")
+
+// ImportRowResult reports what happened to one row of an import. Row is the
+// 1-based position of the row among the data rows of the file.
+type ImportRowResult struct {
+	Row       int        `json:"row"`
+	Status    string     `json:"status"`
+	ProductID *entity.ID `json:"product_id,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// ImportJob tracks a bulk product import. In a dry run every row is checked
+// but nothing is written, and the report says what the import would do.
+type ImportJob struct {
+	ID         entity.ID         `json:"id"`
+	UserID     string            `json:"user_id" gorm:"index"`
+	Format     string            `json:"format"`
+	DryRun     bool              `json:"dry_run"`
+	Status     string            `json:"status"`
+	Total      int               `json:"total"`
+	Processed  int               `json:"processed"`
+	Created    int               `json:"created"`
+	Updated    int               `json:"updated"`
+	Rejected   int               `json:"rejected"`
+	Rows       []ImportRowResult `json:"rows" gorm:"serializer:json"`
+	Error      string            `json:"error,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	FinishedAt *time.Time        `json:"finished_at"`
+}
+
+func NewImportJob(userID, format string, dryRun bool, total int) (*ImportJob, error) {
+	if format != ImportCSV && format != ImportNDJSON {
+		return nil, ErrInvalidImportFormat
+	}
+	return &ImportJob{
+		ID:        entity.NewId(),
+		UserID:    userID,
+		Format:    format,
+		DryRun:    dryRun,
+		Status:    ImportPending,
+		Total:     total,
+		Rows:      []ImportRowResult{},
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// Record adds the result of a row to the report and its counters.
+func (j *ImportJob) Record(result ImportRowResult) {
+	j.Rows = append(j.Rows, result)
+	j.Processed++
+	switch result.Status {
+	case ImportRowCreated:
+		j.Created++
+	case ImportRowUpdated:
+		j.Updated++
+	case ImportRowRejected:
+		j.Rejected++
+	}
+}
+
+func (j *ImportJob) Start() {
+	j.Status = ImportRunning
+}
+
+func (j *ImportJob) Finish(now time.Time) {
+	j.Status = ImportCompleted
+	j.FinishedAt = &now
+}
+
+// Fail stops the job with reason. Rows processed before it failed stay in
+// the report.
+func (j *ImportJob) Fail(reason string, now time.Time) {
+	j.Status = ImportFailed
+	j.Error = reason
+	j.FinishedAt = &now
+}
+
+func (j *ImportJob) IsFinished() bool {
+	return j.Status == ImportCompleted || j.Status == ImportFailed
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewImportJob(t *testing.T) {
+	job, err := NewImportJob("user", ImportCSV, true, 3)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, job.ID)
+	assert.Equal(t, ImportPending, job.Status)
+	assert.True(t, job.DryRun)
+	assert.Equal(t, 3, job.Total)
+	assert.Empty(t, job.Rows)
+	assert.False(t, job.IsFinished())
+
+	_, err = NewImportJob("user", "xml", false, 1)
+	assert.Equal(t, ErrInvalidImportFormat, err)
+}
+
+func TestImportJobRecord(t *testing.T) {
+	job, _ := NewImportJob("user", ImportNDJSON, false, 3)
+	productID := entity.NewId()
+
+	job.Record(ImportRowResult{Row: 1, Status: ImportRowCreated, ProductID: &productID})
+	job.Record(ImportRowResult{Row: 2, Status: ImportRowUpdated, ProductID: &productID})
+	job.Record(ImportRowResult{Row: 3, Status: ImportRowRejected, Error: "name is required"})
+
+	assert.Equal(t, 3, job.Processed)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 1, job.Updated)
+	assert.Equal(t, 1, job.Rejected)
+	assert.Len(t, job.Rows, 3)
+}
+
+func TestImportJobLifecycle(t *testing.T) {
+	job, _ := NewImportJob("user", ImportCSV, false, 1)
+	now := time.Now()
+
+	job.Start()
+	assert.Equal(t, ImportRunning, job.Status)
+	job.Finish(now)
+	assert.Equal(t, ImportCompleted, job.Status)
+	assert.Equal(t, now, *job.FinishedAt)
+	assert.True(t, job.IsFinished())
+
+	failed, _ := NewImportJob("user", ImportCSV, false, 1)
+	failed.Fail("server restarted", now)
+	assert.Equal(t, ImportFailed, failed.Status)
+	assert.Equal(t, "server restarted", failed.Error)
+	assert.True(t, failed.IsFinished())
+}
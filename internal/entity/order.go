@@ -0,0 +1,150 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+const (
+	OrderPending   = "pending"
+	OrderPaid      = "paid"
+	OrderShipped   = "shipped"
+	OrderDelivered = "delivered"
+	OrderCancelled = "cancelled"
+	OrderRefunded  = "refunded"
+)
+
+var (
+	ErrOrderHasNoItems        = errors.New("order needs at least one item")
+	ErrOrderCurrencies        = errors.New("order items must be priced in one currency")
+	ErrInvalidOrderStatus     = errors.New("status must be pending, paid, shipped, delivered, cancelled or refunded")
+	ErrInvalidOrderTransition = errors.New("illegal order status transition")
+)
+
+// orderTransitions lists the statuses each status can move to. Cancelled
+// and refunded orders are final.
+var orderTransitions = map[string][]string{
+	OrderPending:   {OrderPaid, OrderCancelled},
+	OrderPaid:      {OrderShipped, OrderRefunded},
+	OrderShipped:   {OrderDelivered},
+	OrderDelivered: {OrderRefunded},
+	OrderCancelled: nil,
+	OrderRefunded:  nil,
+}
+
+// OrderItem is a line of an order, numbered from 1 by Line. Name and
+// UnitPrice are copied from the product when the order is placed, so later
+// changes to the product leave the order untouched.
+type OrderItem struct {
+	ID        entity.ID    `json:"id"`
+	OrderID   entity.ID    `json:"-" gorm:"index"`
+	Line      int          `json:"line"`
+	ProductID entity.ID    `json:"product_id" gorm:"index"`
+	Name      string       `json:"name"`
+	UnitPrice entity.Money `json:"unit_price" gorm:"embedded;embeddedPrefix:unit_price_"`
+	Quantity  int          `json:"quantity"`
+	Total     entity.Money `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+}
+
+func NewOrderItem(productID entity.ID, name string, unitPrice entity.Money, quantity int) (*OrderItem, error) {
+	if name == "" {
+		return nil, ErrNameIsRequired
+	}
+	if unitPrice.IsNegative() {
+		return nil, ErrInvalidPrice
+	}
+	if !entity.IsCurrency(unitPrice.Currency) {
+		return nil, entity.ErrInvalidCurrency
+	}
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	total, err := unitPrice.Times(int64(quantity))
+	if err != nil {
+		return nil, err
+	}
+	return &OrderItem{
+		ID:        entity.NewId(),
+		ProductID: productID,
+		Name:      name,
+		UnitPrice: unitPrice,
+		Quantity:  quantity,
+		Total:     total,
+	}, nil
+}
+
+// Order is a purchase made by a user. It starts pending and moves through
+// its statuses with Transition.
+type Order struct {
+	ID        entity.ID    `json:"id"`
+	UserID    string       `json:"user_id" gorm:"index"`
+	TenantID  string       `json:"-" gorm:"index"`
+	Status    string       `json:"status" gorm:"index"`
+	Items     []OrderItem  `json:"items"`
+	Total     entity.Money `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// NewOrder places a pending order for userID with the given items, whose
+// totals add up to the order total.
+func NewOrder(userID string, items []*OrderItem) (*Order, error) {
+	if len(items) == 0 {
+		return nil, ErrOrderHasNoItems
+	}
+	now := time.Now()
+	order := &Order{
+		ID:        entity.NewId(),
+		UserID:    userID,
+		Status:    OrderPending,
+		Total:     entity.Money{Currency: items[0].Total.Currency},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for i, item := range items {
+		total, err := order.Total.Add(item.Total)
+		if errors.Is(err, entity.ErrCurrencyMismatch) {
+			return nil, ErrOrderCurrencies
+		}
+		if err != nil {
+			return nil, err
+		}
+		order.Total = total
+		item.OrderID = order.ID
+		item.Line = i + 1
+		order.Items = append(order.Items, *item)
+	}
+	return order, nil
+}
+
+func IsOrderStatus(status string) bool {
+	_, ok := orderTransitions[status]
+	return ok
+}
+
+// CanTransition reports whether the order can move to status.
+func (o *Order) CanTransition(status string) bool {
+	for _, next := range orderTransitions[o.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves the order to status at now, rejecting moves the state
+// machine does not allow.
+func (o *Order) Transition(status string, now time.Time) error {
+	if !IsOrderStatus(status) {
+		return ErrInvalidOrderStatus
+	}
+	if !o.CanTransition(status) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidOrderTransition, o.Status, status)
+	}
+	o.Status = status
+	o.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrderItem(t *testing.T) {
+	productID := entity.NewId()
+	item, err := NewOrderItem(productID, "product 1", brl(1050), 3)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, item.ID)
+	assert.Equal(t, productID, item.ProductID)
+	assert.Equal(t, "product 1", item.Name)
+	assert.Equal(t, brl(1050), item.UnitPrice)
+	assert.Equal(t, brl(3150), item.Total)
+
+	_, err = NewOrderItem(productID, "product 1", brl(1050), 0)
+	assert.Equal(t, ErrInvalidQuantity, err)
+	_, err = NewOrderItem(productID, "", brl(1050), 1)
+	assert.Equal(t, ErrNameIsRequired, err)
+	_, err = NewOrderItem(productID, "product 1", brl(-1), 1)
+	assert.Equal(t, ErrInvalidPrice, err)
+}
+
+func TestNewOrder(t *testing.T) {
+	first, _ := NewOrderItem(entity.NewId(), "product 1", brl(1000), 2)
+	second, _ := NewOrderItem(entity.NewId(), "product 2", brl(550), 1)
+
+	order, err := NewOrder("user-1", []*OrderItem{first, second})
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1", order.UserID)
+	assert.Equal(t, OrderPending, order.Status)
+	assert.Equal(t, brl(2550), order.Total)
+	assert.Len(t, order.Items, 2)
+	assert.Equal(t, order.ID, order.Items[0].OrderID)
+	assert.Equal(t, 1, order.Items[0].Line)
+	assert.Equal(t, 2, order.Items[1].Line)
+
+	_, err = NewOrder("user-1", nil)
+	assert.Equal(t, ErrOrderHasNoItems, err)
+
+	dollars, _ := NewOrderItem(entity.NewId(), "product 3", entity.Money{Amount: 100, Currency: "USD"}, 1)
+	_, err = NewOrder("user-1", []*OrderItem{first, dollars})
+	assert.Equal(t, ErrOrderCurrencies, err)
+}
+
+func TestOrderTransitions(t *testing.T) {
+	item, _ := NewOrderItem(entity.NewId(), "product 1", brl(1000), 1)
+	order, err := NewOrder("user-1", []*OrderItem{item})
+	assert.Nil(t, err)
+
+	now := time.Now().Add(time.Minute)
+	for _, status := range []string{OrderPaid, OrderShipped, OrderDelivered, OrderRefunded} {
+		assert.Nil(t, order.Transition(status, now))
+		assert.Equal(t, status, order.Status)
+	}
+	assert.Equal(t, now, order.UpdatedAt)
+	assert.ErrorIs(t, order.Transition(OrderPending, now), ErrInvalidOrderTransition)
+	assert.Equal(t, OrderRefunded, order.Status)
+}
+
+func TestOrderRejectsIllegalTransitions(t *testing.T) {
+	item, _ := NewOrderItem(entity.NewId(), "product 1", brl(1000), 1)
+	order, _ := NewOrder("user-1", []*OrderItem{item})
+
+	assert.ErrorIs(t, order.Transition(OrderShipped, time.Now()), ErrInvalidOrderTransition)
+	assert.ErrorIs(t, order.Transition(OrderRefunded, time.Now()), ErrInvalidOrderTransition)
+	assert.Equal(t, ErrInvalidOrderStatus, order.Transition("lost", time.Now()))
+	assert.False(t, order.CanTransition(OrderDelivered))
+
+	assert.Nil(t, order.Transition(OrderCancelled, time.Now()))
+	assert.ErrorIs(t, order.Transition(OrderPaid, time.Now()), ErrInvalidOrderTransition)
+	assert.Equal(t, OrderCancelled, order.Status)
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+// Organization is a tenant. Its data, such as its products, is isolated
+// from the data of every other organization.
+type Organization struct {
+	ID        entity.ID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewOrganization(name string) (*Organization, error) {
+	organization := &Organization{
+		ID:        entity.NewId(),
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+	if err := organization.Validate(); err != nil {
+		return nil, err
+	}
+	return organization, nil
+}
+
+func (o *Organization) Validate() error {
+	if o.ID.String() == "" {
+		return ErrIDIsRequired
+	}
+	if _, err := entity.ParseID(o.ID.String()); err != nil {
+		return ErrInvalidID
+	}
+	if o.Name == "" {
+		return ErrNameIsRequired
+	}
+	return nil
+}
+
+// Membership makes a user part of an organization, which lets them sign in
+// to it.
+type Membership struct {
+	UserID         entity.ID `json:"user_id" gorm:"primaryKey"`
+	OrganizationID entity.ID `json:"organization_id" gorm:"primaryKey;index"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func NewMembership(userID, organizationID entity.ID) *Membership {
+	return &Membership{
+		UserID:         userID,
+		OrganizationID: organizationID,
+		CreatedAt:      time.Now(),
+	}
+}
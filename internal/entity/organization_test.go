@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrganization(t *testing.T) {
+	organization, err := NewOrganization("Brand A")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, organization.ID)
+	assert.Equal(t, "Brand A", organization.Name)
+	assert.False(t, organization.CreatedAt.IsZero())
+}
+
+func TestOrganizationWhenNameIsRequired(t *testing.T) {
+	organization, err := NewOrganization("")
+	assert.Nil(t, organization)
+	assert.Equal(t, ErrNameIsRequired, err)
+}
+
+func TestNewMembership(t *testing.T) {
+	userID, organizationID := entity.NewId(), entity.NewId()
+	membership := NewMembership(userID, organizationID)
+	assert.Equal(t, userID, membership.UserID)
+	assert.Equal(t, organizationID, membership.OrganizationID)
+	assert.False(t, membership.CreatedAt.IsZero())
+}
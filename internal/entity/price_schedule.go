@@ -0,0 +1,207 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+var (
+	ErrInvalidPriceWindow       = errors.New("effective_to must be after effective_from")
+	ErrOverlappingPriceSchedule = errors.New("price schedule overlaps another schedule of the product")
+	ErrPriceScheduleCurrency    = errors.New("price schedule must be in the currency of the product price")
+)
+
+// PriceSchedule replaces the price of a product from EffectiveFrom until
+// EffectiveTo, or for good when EffectiveTo is nil. The schedules of a
+// product never overlap, so at most one applies at any time.
+type PriceSchedule struct {
+	ID            entity.ID    `json:"id"`
+	ProductID     entity.ID    `json:"product_id" gorm:"index"`
+	Price         entity.Money `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	EffectiveFrom time.Time    `json:"effective_from" gorm:"index"`
+	EffectiveTo   *time.Time   `json:"effective_to"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+func NewPriceSchedule(productID entity.ID, price entity.Money, from time.Time, to *time.Time) (*PriceSchedule, error) {
+	schedule := &PriceSchedule{
+		ID:            entity.NewId(),
+		ProductID:     productID,
+		Price:         price,
+		EffectiveFrom: from,
+		EffectiveTo:   to,
+		CreatedAt:     time.Now(),
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *PriceSchedule) Validate() error {
+	if s.Price.IsZero() {
+		return ErrPriceIsRequired
+	}
+	if s.Price.IsNegative() {
+		return ErrInvalidPrice
+	}
+	if !entity.IsCurrency(s.Price.Currency) {
+		return entity.ErrInvalidCurrency
+	}
+	if s.EffectiveTo != nil && !s.EffectiveTo.After(s.EffectiveFrom) {
+		return ErrInvalidPriceWindow
+	}
+	return nil
+}
+
+// Covers reports whether the schedule applies at t. Windows include their
+// start and exclude their end.
+func (s *PriceSchedule) Covers(t time.Time) bool {
+	return !t.Before(s.EffectiveFrom) && (s.EffectiveTo == nil || t.Before(*s.EffectiveTo))
+}
+
+func (s *PriceSchedule) Overlaps(other *PriceSchedule) bool {
+	startsBeforeOtherEnds := other.EffectiveTo == nil || s.EffectiveFrom.Before(*other.EffectiveTo)
+	endsAfterOtherStarts := s.EffectiveTo == nil || s.EffectiveTo.After(other.EffectiveFrom)
+	return startsBeforeOtherEnds && endsAfterOtherStarts
+}
+
+// ValidatePriceSchedules checks each schedule and that no two of them
+// overlap.
+func ValidatePriceSchedules(schedules []*PriceSchedule) error {
+	for i, schedule := range schedules {
+		if err := schedule.Validate(); err != nil {
+			return err
+		}
+		for _, other := range schedules[i+1:] {
+			if schedule.Overlaps(other) {
+				return ErrOverlappingPriceSchedule
+			}
+		}
+	}
+	return nil
+}
+
+// EffectivePrice is the price a product sells for at a point in time, with
+// the schedule it comes from, if any.
+type EffectivePrice struct {
+	Price       entity.Money `json:"price"`
+	ScheduleID  *entity.ID   `json:"schedule_id,omitempty"`
+	EffectiveTo *time.Time   `json:"effective_to,omitempty"`
+}
+
+// ResolvePrice returns the effective price of product given the schedule
+// that applies, which may be nil.
+func ResolvePrice(product *Product, schedule *PriceSchedule) EffectivePrice {
+	if schedule == nil {
+		return EffectivePrice{Price: product.Price}
+	}
+	return EffectivePrice{Price: schedule.Price, ScheduleID: &schedule.ID, EffectiveTo: schedule.EffectiveTo}
+}
+
+// PricePoint is a change of the base price of a product.
+type PricePoint struct {
+	Price entity.Money
+	From  time.Time
+}
+
+// PricePoints extracts the base price changes from the history of a
+// product, oldest first.
+func PricePoints(history []*ProductHistory) []PricePoint {
+	var points []PricePoint
+	for _, entry := range history {
+		if entry.Action != ProductCreated && entry.Action != ProductUpdated {
+			continue
+		}
+		change, ok := entry.Changes["price"]
+		if !ok {
+			continue
+		}
+		raw, err := json.Marshal(change.After)
+		if err != nil {
+			continue
+		}
+		var price entity.Money
+		if err := json.Unmarshal(raw, &price); err != nil {
+			continue
+		}
+		points = append(points, PricePoint{Price: price, From: entry.CreatedAt})
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].From.Before(points[j].From) })
+	return points
+}
+
+// PriceTimelineEntry is a period during which a product had one price. To
+// is nil for the last, open ended period.
+type PriceTimelineEntry struct {
+	Price      entity.Money `json:"price"`
+	From       time.Time    `json:"from"`
+	To         *time.Time   `json:"to"`
+	ScheduleID *entity.ID   `json:"schedule_id,omitempty"`
+}
+
+// BuildPriceTimeline lays the schedules over the base price changes and
+// returns the resulting periods in order. Schedules win over the base price
+// while they apply, and consecutive periods with the same price and source
+// are merged.
+func BuildPriceTimeline(points []PricePoint, schedules []*PriceSchedule) []PriceTimelineEntry {
+	var bounds []time.Time
+	for _, point := range points {
+		bounds = append(bounds, point.From)
+	}
+	for _, schedule := range schedules {
+		bounds = append(bounds, schedule.EffectiveFrom)
+		if schedule.EffectiveTo != nil {
+			bounds = append(bounds, *schedule.EffectiveTo)
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Before(bounds[j]) })
+
+	var timeline []PriceTimelineEntry
+	for i, from := range bounds {
+		if i > 0 && from.Equal(bounds[i-1]) {
+			continue
+		}
+		entry, ok := priceAt(points, schedules, from)
+		if n := len(timeline); n > 0 && timeline[n-1].To == nil {
+			last := &timeline[n-1]
+			if ok && last.Price == entry.Price && sameSchedule(last.ScheduleID, entry.ScheduleID) {
+				continue
+			}
+			to := from
+			last.To = &to
+		}
+		// Before the first base price there is nothing to fall back to once
+		// a schedule ends, which leaves a gap in the timeline.
+		if ok {
+			timeline = append(timeline, entry)
+		}
+	}
+	return timeline
+}
+
+// priceAt returns the price in effect at t and whether there is one.
+func priceAt(points []PricePoint, schedules []*PriceSchedule, t time.Time) (PriceTimelineEntry, bool) {
+	for _, schedule := range schedules {
+		if schedule.Covers(t) {
+			return PriceTimelineEntry{Price: schedule.Price, From: t, ScheduleID: &schedule.ID}, true
+		}
+	}
+	for i := len(points) - 1; i >= 0; i-- {
+		if !points[i].From.After(t) {
+			return PriceTimelineEntry{Price: points[i].Price, From: t}, true
+		}
+	}
+	return PriceTimelineEntry{}, false
+}
+
+func sameSchedule(a, b *entity.ID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
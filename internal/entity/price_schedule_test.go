@@ -0,0 +1,108 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, time.March, d, 0, 0, 0, 0, time.UTC)
+}
+
+func dayPtr(d int) *time.Time {
+	t := day(d)
+	return &t
+}
+
+func TestNewPriceSchedule(t *testing.T) {
+	productID := entity.NewId()
+
+	schedule, err := NewPriceSchedule(productID, brl(900), day(1), dayPtr(8))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, schedule.ID)
+	assert.True(t, schedule.Covers(day(1)))
+	assert.True(t, schedule.Covers(day(7)))
+	assert.False(t, schedule.Covers(day(8)))
+
+	_, err = NewPriceSchedule(productID, brl(900), day(8), dayPtr(8))
+	assert.Equal(t, ErrInvalidPriceWindow, err)
+	_, err = NewPriceSchedule(productID, brl(0), day(1), nil)
+	assert.Equal(t, ErrPriceIsRequired, err)
+	_, err = NewPriceSchedule(productID, entity.Money{Amount: 100, Currency: "XXX"}, day(1), nil)
+	assert.Equal(t, entity.ErrInvalidCurrency, err)
+}
+
+func TestValidatePriceSchedules(t *testing.T) {
+	productID := entity.NewId()
+	week, _ := NewPriceSchedule(productID, brl(900), day(1), dayPtr(8))
+	nextWeek, _ := NewPriceSchedule(productID, brl(800), day(8), dayPtr(15))
+	assert.Nil(t, ValidatePriceSchedules([]*PriceSchedule{week, nextWeek}))
+
+	overlapping, _ := NewPriceSchedule(productID, brl(700), day(7), dayPtr(9))
+	assert.Equal(t, ErrOverlappingPriceSchedule, ValidatePriceSchedules([]*PriceSchedule{week, nextWeek, overlapping}))
+
+	forever, _ := NewPriceSchedule(productID, brl(700), day(20), nil)
+	assert.Nil(t, ValidatePriceSchedules([]*PriceSchedule{week, forever}))
+	before, _ := NewPriceSchedule(productID, brl(700), day(14), dayPtr(21))
+	assert.Equal(t, ErrOverlappingPriceSchedule, ValidatePriceSchedules([]*PriceSchedule{forever, before}))
+}
+
+func TestResolvePrice(t *testing.T) {
+	product, _ := NewProduct("Shirt", brl(1000))
+	assert.Equal(t, EffectivePrice{Price: brl(1000)}, ResolvePrice(product, nil))
+
+	sale, _ := NewPriceSchedule(product.ID, brl(800), day(1), dayPtr(8))
+	price := ResolvePrice(product, sale)
+	assert.Equal(t, brl(800), price.Price)
+	assert.Equal(t, sale.ID, *price.ScheduleID)
+	assert.Equal(t, day(8), *price.EffectiveTo)
+}
+
+func TestPricePoints(t *testing.T) {
+	product, _ := NewProduct("Shirt", brl(1000))
+	created, _ := NewProductHistory(product.ID, ProductCreated, "user", nil, product)
+	created.CreatedAt = day(1)
+
+	renamed := *product
+	renamed.Name = "T-Shirt"
+	rename, _ := NewProductHistory(product.ID, ProductUpdated, "user", product, &renamed)
+	rename.CreatedAt = day(2)
+
+	repriced := renamed
+	repriced.Price = brl(1200)
+	reprice, _ := NewProductHistory(product.ID, ProductUpdated, "user", &renamed, &repriced)
+	reprice.CreatedAt = day(3)
+
+	points := PricePoints([]*ProductHistory{created, rename, reprice})
+	assert.Equal(t, []PricePoint{{Price: brl(1000), From: day(1)}, {Price: brl(1200), From: day(3)}}, points)
+}
+
+func TestBuildPriceTimeline(t *testing.T) {
+	productID := entity.NewId()
+	points := []PricePoint{{Price: brl(1000), From: day(1)}, {Price: brl(1200), From: day(10)}}
+	sale, _ := NewPriceSchedule(productID, brl(800), day(5), dayPtr(12))
+	clearance, _ := NewPriceSchedule(productID, brl(500), day(20), nil)
+
+	timeline := BuildPriceTimeline(points, []*PriceSchedule{clearance, sale})
+	assert.Equal(t, []PriceTimelineEntry{
+		{Price: brl(1000), From: day(1), To: dayPtr(5)},
+		{Price: brl(800), From: day(5), To: dayPtr(12), ScheduleID: &sale.ID},
+		{Price: brl(1200), From: day(12), To: dayPtr(20)},
+		{Price: brl(500), From: day(20), ScheduleID: &clearance.ID},
+	}, timeline)
+}
+
+func TestBuildPriceTimelineMergesAndLeavesGaps(t *testing.T) {
+	productID := entity.NewId()
+	points := []PricePoint{{Price: brl(1000), From: day(10)}, {Price: brl(1000), From: day(12)}}
+	early, _ := NewPriceSchedule(productID, brl(800), day(1), dayPtr(5))
+
+	timeline := BuildPriceTimeline(points, []*PriceSchedule{early})
+	assert.Equal(t, []PriceTimelineEntry{
+		{Price: brl(800), From: day(1), To: dayPtr(5), ScheduleID: &early.ID},
+		{Price: brl(1000), From: day(10)},
+	}, timeline)
+}
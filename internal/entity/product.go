@@ -2,9 +2,12 @@ package entity
 
 import (
 	"errors"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"gorm.io/gorm"
 )
 
 var (
@@ -16,10 +19,30 @@ var (
 )
 
 type Product struct {
-	ID        entity.ID `json:"id"`
-	Name      string    `json:"name"`
-	Price     float64   `json:"price"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         entity.ID      `json:"id"`
+	Name       string         `json:"name"`
+	Price      entity.Money   `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	CategoryID *entity.ID     `json:"category_id" gorm:"index"`
+	OwnerID    string         `json:"owner_id" gorm:"index"`
+	TenantID   string         `json:"-" gorm:"index;uniqueIndex:idx_products_tenant_slug,priority:1;uniqueIndex:idx_products_tenant_sku,priority:1"`
+	Tags       []Tag          `json:"tags" gorm:"many2many:product_tags" swaggertype:"array,string"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Version    int            `json:"version"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggertype:"string" format:"date-time"`
+	// Slug and SKU are unique in the tenant. A product has no slug, stored
+	// as NULL, until the repository generates one from its name.
+	Slug string  `json:"slug" gorm:"default:null;uniqueIndex:idx_products_tenant_slug,priority:2"`
+	SKU  *string `json:"sku" gorm:"uniqueIndex:idx_products_tenant_sku,priority:2"`
+	// Status moves through Transition. Products stored before statuses
+	// existed default to published so they stay live.
+	Status      string     `json:"status" gorm:"index;default:published"`
+	PublishedAt *time.Time `json:"published_at"`
+	// ReviewCount, RatingSum and AverageRating aggregate the approved
+	// reviews of the product. The review repository keeps them up to date
+	// as reviews change, so they are never written with the product.
+	ReviewCount   int     `json:"review_count"`
+	RatingSum     int     `json:"-"`
+	AverageRating float64 `json:"average_rating" gorm:"index"`
 }
 
 func (p *Product) Validate() error {
@@ -32,21 +55,78 @@ func (p *Product) Validate() error {
 	if p.Name == "" {
 		return ErrNameIsRequired
 	}
-	if p.Price == 0 {
+	if p.Price.IsZero() {
 		return ErrPriceIsRequired
 	}
-	if p.Price < 0 {
+	if p.Price.IsNegative() {
 		return ErrInvalidPrice
 	}
-	return nil
+	if !entity.IsCurrency(p.Price.Currency) {
+		return entity.ErrInvalidCurrency
+	}
+	if !IsProductStatus(p.Status) {
+		return ErrInvalidProductStatus
+	}
+	// An empty slug is generated from the name when the product is saved.
+	if p.Slug != "" && !IsSlug(p.Slug) {
+		return ErrInvalidSlug
+	}
+	if err := validateSKU(p.SKU); err != nil {
+		return err
+	}
+	return validateTags(p.Tags)
+}
+
+// SetSKU sets the SKU of the product, trimming spaces. A nil or blank sku
+// removes it.
+func (p *Product) SetSKU(sku *string) {
+	p.SKU = nil
+	if sku != nil {
+		if trimmed := strings.TrimSpace(*sku); trimmed != "" {
+			p.SKU = &trimmed
+		}
+	}
+}
+
+// SetTags replaces the tags of the product with the normalized names.
+func (p *Product) SetTags(names []string) {
+	p.Tags = NewTags(names)
+}
+
+// IsOwnedBy reports whether userID created the product. Products created
+// before owners were recorded have none and are owned by nobody.
+func (p *Product) IsOwnedBy(userID string) bool {
+	return userID != "" && p.OwnerID == userID
+}
+
+// ConvertPrice returns the price in the target currency of rate, which must
+// convert from the product currency. See ExchangeRate.Convert for rounding.
+func (p *Product) ConvertPrice(rate *ExchangeRate) (entity.Money, error) {
+	return rate.Convert(p.Price)
+}
+
+// ChangedFields returns the names of the fields whose values differ between
+// p and updated.
+func (p *Product) ChangedFields(updated *Product) []string {
+	var fields []string
+	before, after := reflect.ValueOf(p).Elem(), reflect.ValueOf(updated).Elem()
+	for i := 0; i < before.NumField(); i++ {
+		if !reflect.DeepEqual(before.Field(i).Interface(), after.Field(i).Interface()) {
+			fields = append(fields, before.Type().Field(i).Name)
+		}
+	}
+	return fields
 }
 
-func NewProduct(name string, price float64) (*Product, error) {
+func NewProduct(name string, price entity.Money) (*Product, error) {
 	product := &Product{
 		ID:        entity.NewId(),
 		Name:      name,
 		Price:     price,
+		Tags:      []Tag{},
+		Status:    ProductDraft,
 		CreatedAt: time.Now(),
+		Version:   1,
 	}
 
 	if err := product.Validate(); err != nil {
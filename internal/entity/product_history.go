@@ -0,0 +1,125 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+const (
+	ProductCreated  = "create"
+	ProductUpdated  = "update"
+	ProductDeleted  = "delete"
+	ProductRestored = "restore"
+	ProductPurged   = "purge"
+)
+
+var ErrInvalidChanges = errors.New("invalid product changes")
+
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ProductChanges maps the JSON name of each changed product field to its
+// previous and new values. It is stored as a JSON column.
+type ProductChanges map[string]FieldChange
+
+func (c ProductChanges) Value() (driver.Value, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+func (c *ProductChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case []byte:
+		return json.Unmarshal(v, c)
+	}
+	return ErrInvalidChanges
+}
+
+// ProductHistory records a single change made to a product and who made it.
+type ProductHistory struct {
+	ID        entity.ID      `json:"id"`
+	ProductID entity.ID      `json:"product_id" gorm:"index"`
+	Action    string         `json:"action"`
+	UserID    string         `json:"user_id"`
+	TenantID  string         `json:"-" gorm:"index"`
+	Changes   ProductChanges `json:"changes" gorm:"type:text" swaggertype:"object"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// NewProductHistory diffs before and after, either of which may be nil for
+// creations and purges. The entry belongs to the tenant of the product.
+func NewProductHistory(productID entity.ID, action, userID string, before, after *Product) (*ProductHistory, error) {
+	changes, err := diffProducts(before, after)
+	if err != nil {
+		return nil, err
+	}
+	product := after
+	if product == nil {
+		product = before
+	}
+	var tenantID string
+	if product != nil {
+		tenantID = product.TenantID
+	}
+	return &ProductHistory{
+		ID:        entity.NewId(),
+		ProductID: productID,
+		Action:    action,
+		UserID:    userID,
+		TenantID:  tenantID,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+func diffProducts(before, after *Product) (ProductChanges, error) {
+	beforeFields, err := productFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := productFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := ProductChanges{}
+	for name, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[name]) {
+			changes[name] = FieldChange{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = FieldChange{After: value}
+		}
+	}
+	return changes, nil
+}
+
+func productFields(p *Product) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if p == nil {
+		return fields, nil
+	}
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(raw, &fields)
+	return fields, err
+}
@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProductHistory(t *testing.T) {
+	before, err := NewProduct("product 1", brl(1000))
+	assert.Nil(t, err)
+	after := *before
+	after.Name = "product 2"
+
+	history, err := NewProductHistory(before.ID, ProductUpdated, "user-1", before, &after)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, history.ID)
+	assert.Equal(t, before.ID, history.ProductID)
+	assert.Equal(t, "user-1", history.UserID)
+	assert.Equal(t, ProductChanges{"name": {Before: "product 1", After: "product 2"}}, history.Changes)
+}
+
+func TestNewProductHistoryWithoutPreviousState(t *testing.T) {
+	product, err := NewProduct("product 1", brl(1000))
+	assert.Nil(t, err)
+
+	history, err := NewProductHistory(product.ID, ProductCreated, "user-1", nil, product)
+	assert.Nil(t, err)
+	assert.Nil(t, history.Changes["name"].Before)
+	assert.Equal(t, "product 1", history.Changes["name"].After)
+	assert.Equal(t, map[string]interface{}{"amount": "10.00", "currency": "BRL"}, history.Changes["price"].After)
+}
+
+func TestNewProductHistoryBelongsToProductTenant(t *testing.T) {
+	product, err := NewProduct("product 1", brl(1000))
+	assert.Nil(t, err)
+	product.TenantID = "tenant-1"
+
+	history, err := NewProductHistory(product.ID, ProductPurged, "user-1", product, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "tenant-1", history.TenantID)
+	assert.NotContains(t, history.Changes, "tenant_id")
+}
@@ -0,0 +1,102 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+// Images are decoded in memory to generate thumbnails, so their dimensions
+// are capped whatever their file size: a small file can declare a huge
+// image.
+const (
+	MaxImageSide   = 8000
+	MaxImagePixels = 25_000_000
+)
+
+var (
+	ErrUnsupportedImageType = errors.New("image must be a JPEG, PNG or GIF")
+	ErrInvalidThumbnailSize = errors.New("invalid thumbnail size")
+	ErrImageTooLarge        = errors.New("image must be at most 8000 pixels wide and high and 25 megapixels")
+)
+
+// ImageContentTypes lists the image types products accept.
+var ImageContentTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// ThumbnailSizes maps the name of each thumbnail generated for an image to
+// the side, in pixels, of the box it is scaled to fit in.
+var ThumbnailSizes = map[string]int{
+	"small":  128,
+	"medium": 512,
+}
+
+// ProductImage describes an uploaded picture of a product. The files
+// themselves live in blob storage under keys derived from the ids.
+type ProductImage struct {
+	ID          entity.ID `json:"id"`
+	ProductID   entity.ID `json:"product_id" gorm:"index"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Position    int       `json:"position"`
+	Primary     bool      `json:"primary" gorm:"column:is_primary"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func NewProductImage(productID entity.ID, contentType string, size int64, width, height int) (*ProductImage, error) {
+	if !IsImageContentType(contentType) {
+		return nil, ErrUnsupportedImageType
+	}
+	if err := CheckImageDimensions(width, height); err != nil {
+		return nil, err
+	}
+	return &ProductImage{
+		ID:          entity.NewId(),
+		ProductID:   productID,
+		ContentType: contentType,
+		Size:        size,
+		Width:       width,
+		Height:      height,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// CheckImageDimensions returns ErrImageTooLarge for images too large to be
+// decoded.
+func CheckImageDimensions(width, height int) error {
+	if width > MaxImageSide || height > MaxImageSide || int64(width)*int64(height) > MaxImagePixels {
+		return ErrImageTooLarge
+	}
+	return nil
+}
+
+func IsImageContentType(contentType string) bool {
+	for _, t := range ImageContentTypes {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *ProductImage) OriginalKey() string {
+	return "products/" + i.ProductID.String() + "/images/" + i.ID.String() + "/original"
+}
+
+func (i *ProductImage) ThumbnailKey(size string) (string, error) {
+	if _, ok := ThumbnailSizes[size]; !ok {
+		return "", ErrInvalidThumbnailSize
+	}
+	return "products/" + i.ProductID.String() + "/images/" + i.ID.String() + "/" + size, nil
+}
+
+// ThumbnailContentType is the type thumbnails are encoded in. JPEG stays
+// JPEG and everything else becomes PNG, which keeps transparency.
+func (i *ProductImage) ThumbnailContentType() string {
+	if i.ContentType == "image/jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
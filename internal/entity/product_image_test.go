@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProductImage(t *testing.T) {
+	productID := entity.NewId()
+	image, err := NewProductImage(productID, "image/gif", 100, 10, 20)
+	assert.Nil(t, err)
+	assert.Equal(t, "products/"+productID.String()+"/images/"+image.ID.String()+"/original", image.OriginalKey())
+	assert.Equal(t, "image/png", image.ThumbnailContentType())
+
+	key, err := image.ThumbnailKey("small")
+	assert.Nil(t, err)
+	assert.Equal(t, "products/"+productID.String()+"/images/"+image.ID.String()+"/small", key)
+	_, err = image.ThumbnailKey("huge")
+	assert.Equal(t, ErrInvalidThumbnailSize, err)
+}
+
+func TestProductImageWhenTypeIsUnsupported(t *testing.T) {
+	_, err := NewProductImage(entity.NewId(), "image/svg+xml", 100, 10, 20)
+	assert.Equal(t, ErrUnsupportedImageType, err)
+}
+
+func TestProductImageWhenTooLarge(t *testing.T) {
+	_, err := NewProductImage(entity.NewId(), "image/png", 100, MaxImageSide+1, 10)
+	assert.Equal(t, ErrImageTooLarge, err)
+	_, err = NewProductImage(entity.NewId(), "image/png", 100, MaxImageSide, MaxImageSide)
+	assert.Equal(t, ErrImageTooLarge, err)
+	assert.Nil(t, CheckImageDimensions(5000, 5000))
+}
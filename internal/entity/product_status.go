@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Products start as drafts and are only listed to everyone once published.
+// Archived products are retired from sale but kept for orders and links.
+const (
+	ProductDraft     = "draft"
+	ProductPublished = "published"
+	ProductArchived  = "archived"
+)
+
+var (
+	ErrInvalidProductStatus     = errors.New("status must be draft, published or archived")
+	ErrInvalidProductTransition = errors.New("illegal product status transition")
+)
+
+// productTransitions lists the statuses each status can move to. Drafts are
+// deleted rather than archived, and archived products are published again
+// rather than reworked as drafts.
+var productTransitions = map[string][]string{
+	ProductDraft:     {ProductPublished},
+	ProductPublished: {ProductDraft, ProductArchived},
+	ProductArchived:  {ProductPublished},
+}
+
+func IsProductStatus(status string) bool {
+	_, ok := productTransitions[status]
+	return ok
+}
+
+// IsPublished reports whether the product is live, that is listed to
+// everyone and for sale.
+func (p *Product) IsPublished() bool {
+	return p.Status == ProductPublished
+}
+
+// CanTransition reports whether the product can move to status.
+func (p *Product) CanTransition(status string) bool {
+	for _, next := range productTransitions[p.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves the product to status, rejecting moves the state machine
+// does not allow. Publishing records now as PublishedAt, which is kept when
+// the product is unpublished or archived.
+func (p *Product) Transition(status string, now time.Time) error {
+	if !IsProductStatus(status) {
+		return ErrInvalidProductStatus
+	}
+	if !p.CanTransition(status) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidProductTransition, p.Status, status)
+	}
+	p.Status = status
+	if status == ProductPublished {
+		p.PublishedAt = &now
+	}
+	return nil
+}
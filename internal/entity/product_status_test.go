@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductTransitions(t *testing.T) {
+	product, err := NewProduct("Shirt", brl(1000))
+	assert.Nil(t, err)
+	assert.Equal(t, ProductDraft, product.Status)
+	assert.False(t, product.IsPublished())
+	assert.Nil(t, product.PublishedAt)
+
+	published := time.Now().Add(time.Minute)
+	assert.Nil(t, product.Transition(ProductPublished, published))
+	assert.True(t, product.IsPublished())
+	assert.Equal(t, published, *product.PublishedAt)
+
+	later := published.Add(time.Hour)
+	assert.Nil(t, product.Transition(ProductArchived, later))
+	assert.Equal(t, ProductArchived, product.Status)
+	assert.Equal(t, published, *product.PublishedAt)
+
+	assert.Nil(t, product.Transition(ProductPublished, later))
+	assert.Equal(t, later, *product.PublishedAt)
+	assert.Nil(t, product.Transition(ProductDraft, later))
+	assert.Equal(t, ProductDraft, product.Status)
+}
+
+func TestProductRejectsIllegalTransitions(t *testing.T) {
+	product, _ := NewProduct("Shirt", brl(1000))
+
+	assert.ErrorIs(t, product.Transition(ProductArchived, time.Now()), ErrInvalidProductTransition)
+	assert.ErrorIs(t, product.Transition(ProductDraft, time.Now()), ErrInvalidProductTransition)
+	assert.Equal(t, ErrInvalidProductStatus, product.Transition("hidden", time.Now()))
+	assert.Equal(t, ProductDraft, product.Status)
+
+	product.Status = ProductArchived
+	assert.False(t, product.CanTransition(ProductDraft))
+	assert.ErrorIs(t, product.Transition(ProductDraft, time.Now()), ErrInvalidProductTransition)
+
+	product.Status = "hidden"
+	assert.Equal(t, ErrInvalidProductStatus, product.Validate())
+}
@@ -3,40 +3,74 @@ package entity
 import (
 	"testing"
 
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
 	"github.com/stretchr/testify/assert"
 )
 
+func brl(cents int64) entity.Money {
+	return entity.Money{Amount: cents, Currency: "BRL"}
+}
+
 func TestNewProduct(t *testing.T) {
-	p, err := NewProduct("product 1", 10)
+	p, err := NewProduct("product 1", brl(1000))
 	assert.Nil(t, err)
 	assert.NotNil(t, p)
 	assert.NotEmpty(t, p.ID)
 	assert.Equal(t, "product 1", p.Name)
-	assert.Equal(t, 10.0, p.Price)
+	assert.Equal(t, brl(1000), p.Price)
 }
 
 func TestProductWhenNameIsRequired(t *testing.T) {
-	p, err := NewProduct("", 10)
+	p, err := NewProduct("", brl(1000))
 	assert.Nil(t, p)
 	assert.NotNil(t, err)
 	assert.Equal(t, ErrNameIsRequired, err)
 }
 
 func TestProductWhenPriceIsRequired(t *testing.T) {
-	p, err := NewProduct("product 1", 0)
+	p, err := NewProduct("product 1", brl(0))
 	assert.Nil(t, p)
 	assert.NotNil(t, err)
 	assert.Equal(t, ErrPriceIsRequired, err)
 }
 
 func TestProductWhenPriceIsInvalid(t *testing.T) {
-	p, err := NewProduct("product 1", -10)
+	p, err := NewProduct("product 1", brl(-1000))
 	assert.Nil(t, p)
 	assert.Equal(t, ErrInvalidPrice, err)
 }
 
 func TestProductValidate(t *testing.T) {
-	p, err := NewProduct("product 1", 10)
+	p, err := NewProduct("product 1", brl(1000))
 	assert.Nil(t, err)
 	assert.Nil(t, p.Validate())
 }
+
+func TestProductChangedFields(t *testing.T) {
+	p, err := NewProduct("product 1", brl(1000))
+	assert.Nil(t, err)
+
+	updated := *p
+	assert.Empty(t, p.ChangedFields(&updated))
+
+	updated.Price = brl(2000)
+	assert.Equal(t, []string{"Price"}, p.ChangedFields(&updated))
+}
+
+func TestProductWhenCurrencyIsInvalid(t *testing.T) {
+	p, err := NewProduct("product 1", entity.Money{Amount: 1000, Currency: "XYZ"})
+	assert.Nil(t, p)
+	assert.Equal(t, entity.ErrInvalidCurrency, err)
+}
+
+func TestProductIsOwnedBy(t *testing.T) {
+	p, err := NewProduct("product 1", brl(1000))
+	assert.Nil(t, err)
+	assert.False(t, p.IsOwnedBy(""))
+	assert.False(t, p.IsOwnedBy("user 1"))
+
+	p.OwnerID = "user 1"
+	assert.True(t, p.IsOwnedBy("user 1"))
+	assert.False(t, p.IsOwnedBy("user 2"))
+	assert.False(t, p.IsOwnedBy(""))
+}
@@ -0,0 +1,133 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+const (
+	MinStars = 1
+	MaxStars = 5
+
+	maxReviewTitleLength = 120
+	maxReviewBodyLength  = 5000
+)
+
+// Reviews start pending and only count towards the product rating once a
+// moderator approves them.
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+var (
+	ErrInvalidStars        = errors.New("stars must be between 1 and 5")
+	ErrTitleIsRequired     = errors.New("title is required")
+	ErrReviewTitleTooLong  = errors.New("title must be at most 120 characters")
+	ErrReviewBodyTooLong   = errors.New("body must be at most 5000 characters")
+	ErrAuthorIsRequired    = errors.New("author is required")
+	ErrInvalidReviewStatus = errors.New("status must be pending, approved or rejected")
+)
+
+// Review is the opinion of a user about a product. A user reviews each
+// product at most once.
+type Review struct {
+	ID        entity.ID `json:"id"`
+	ProductID entity.ID `json:"product_id" gorm:"uniqueIndex:idx_review_author"`
+	AuthorID  string    `json:"author_id" gorm:"uniqueIndex:idx_review_author"`
+	Stars     int       `json:"stars"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Status    string    `json:"status" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewReview(productID entity.ID, authorID string, stars int, title, body string) (*Review, error) {
+	now := time.Now()
+	review := &Review{
+		ID:        entity.NewId(),
+		ProductID: productID,
+		AuthorID:  authorID,
+		Stars:     stars,
+		Title:     strings.TrimSpace(title),
+		Body:      strings.TrimSpace(body),
+		Status:    ReviewPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := review.Validate(); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (r *Review) Validate() error {
+	if r.AuthorID == "" {
+		return ErrAuthorIsRequired
+	}
+	if r.Stars < MinStars || r.Stars > MaxStars {
+		return ErrInvalidStars
+	}
+	if r.Title == "" {
+		return ErrTitleIsRequired
+	}
+	if utf8.RuneCountInString(r.Title) > maxReviewTitleLength {
+		return ErrReviewTitleTooLong
+	}
+	if utf8.RuneCountInString(r.Body) > maxReviewBodyLength {
+		return ErrReviewBodyTooLong
+	}
+	if !IsReviewStatus(r.Status) {
+		return ErrInvalidReviewStatus
+	}
+	return nil
+}
+
+// Edit replaces the content of the review. Edited reviews go back to
+// pending so moderators see the new content.
+func (r *Review) Edit(stars int, title, body string, now time.Time) error {
+	edited := *r
+	edited.Stars = stars
+	edited.Title = strings.TrimSpace(title)
+	edited.Body = strings.TrimSpace(body)
+	edited.Status = ReviewPending
+	edited.UpdatedAt = now
+	if err := edited.Validate(); err != nil {
+		return err
+	}
+	*r = edited
+	return nil
+}
+
+// Moderate sets the moderation status of the review.
+func (r *Review) Moderate(status string, now time.Time) error {
+	if !IsReviewStatus(status) {
+		return ErrInvalidReviewStatus
+	}
+	r.Status = status
+	r.UpdatedAt = now
+	return nil
+}
+
+// Rating returns what the review adds to the rating aggregates of its
+// product: its stars and a count of one when approved, nothing otherwise.
+func (r *Review) Rating() (stars, count int) {
+	if r.Status != ReviewApproved {
+		return 0, 0
+	}
+	return r.Stars, 1
+}
+
+func IsReviewStatus(status string) bool {
+	switch status {
+	case ReviewPending, ReviewApproved, ReviewRejected:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReview(t *testing.T) {
+	productID := entity.NewId()
+	review, err := NewReview(productID, "user-1", 4, " Good ", "Works well")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, review.ID)
+	assert.Equal(t, productID, review.ProductID)
+	assert.Equal(t, "Good", review.Title)
+	assert.Equal(t, ReviewPending, review.Status)
+
+	_, err = NewReview(productID, "user-1", 0, "Good", "")
+	assert.Equal(t, ErrInvalidStars, err)
+	_, err = NewReview(productID, "user-1", 6, "Good", "")
+	assert.Equal(t, ErrInvalidStars, err)
+	_, err = NewReview(productID, "user-1", 3, " ", "")
+	assert.Equal(t, ErrTitleIsRequired, err)
+	_, err = NewReview(productID, "", 3, "Good", "")
+	assert.Equal(t, ErrAuthorIsRequired, err)
+	_, err = NewReview(productID, "user-1", 3, strings.Repeat("a", 121), "")
+	assert.Equal(t, ErrReviewTitleTooLong, err)
+}
+
+func TestEditReview(t *testing.T) {
+	review, _ := NewReview(entity.NewId(), "user-1", 4, "Good", "")
+	review.Status = ReviewApproved
+
+	now := time.Now().Add(time.Hour)
+	assert.Nil(t, review.Edit(2, "Not so good", "Broke", now))
+	assert.Equal(t, 2, review.Stars)
+	assert.Equal(t, ReviewPending, review.Status)
+	assert.Equal(t, now, review.UpdatedAt)
+
+	assert.Equal(t, ErrInvalidStars, review.Edit(9, "Bad", "", now))
+	assert.Equal(t, 2, review.Stars)
+}
+
+func TestReviewRating(t *testing.T) {
+	review, _ := NewReview(entity.NewId(), "user-1", 4, "Good", "")
+	stars, count := review.Rating()
+	assert.Equal(t, 0, stars)
+	assert.Equal(t, 0, count)
+
+	assert.Nil(t, review.Moderate(ReviewApproved, time.Now()))
+	stars, count = review.Rating()
+	assert.Equal(t, 4, stars)
+	assert.Equal(t, 1, count)
+
+	assert.Equal(t, ErrInvalidReviewStatus, review.Moderate("hidden", time.Now()))
+}
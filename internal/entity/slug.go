@@ -0,0 +1,109 @@
+package entity
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+const (
+	maxSlugLength = 80
+	maxSKULength  = 64
+
+	// defaultSlug is used for names without a single letter or digit.
+	defaultSlug = "product"
+)
+
+var (
+	ErrInvalidSlug = errors.New("slug must be lowercase letters and digits separated by single hyphens, up to 80 characters")
+	ErrInvalidSKU  = errors.New("sku must not be empty, contain spaces or be longer than 64 characters")
+)
+
+// slugFolds replaces the accented letters common in product names with
+// their plain ASCII form.
+var slugFolds = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a", "å", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n", "ß", "ss", "æ", "ae", "œ", "oe", "ø", "o",
+)
+
+// Slugify turns name into a slug such as "cafe-com-leite" for "Café com
+// Leite". Anything but ASCII letters and digits becomes a hyphen.
+func Slugify(name string) string {
+	folded := slugFolds.Replace(strings.ToLower(name))
+	var b strings.Builder
+	hyphen := false
+	for _, c := range folded {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(c)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	if slug == "" {
+		return defaultSlug
+	}
+	return slug
+}
+
+// NumberedSlug returns base followed by "-n", shortening base so the result
+// still fits in a slug.
+func NumberedSlug(base string, n int) string {
+	suffix := "-" + strconv.Itoa(n)
+	if len(base)+len(suffix) > maxSlugLength {
+		base = strings.TrimRight(base[:maxSlugLength-len(suffix)], "-")
+	}
+	return base + suffix
+}
+
+// IsSlug reports whether s is a slug as Slugify makes them.
+func IsSlug(s string) bool {
+	if s == "" || len(s) > maxSlugLength {
+		return false
+	}
+	for _, part := range strings.Split(s, "-") {
+		if part == "" {
+			return false
+		}
+		for _, c := range part {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func validateSKU(sku *string) error {
+	if sku == nil {
+		return nil
+	}
+	if *sku == "" || strings.ContainsAny(*sku, " \t\r\n") || utf8.RuneCountInString(*sku) > maxSKULength {
+		return ErrInvalidSKU
+	}
+	return nil
+}
+
+// SlugRedirect keeps a slug a product no longer uses pointing at it, so
+// links to the old slug keep working.
+type SlugRedirect struct {
+	TenantID  string    `json:"-" gorm:"primaryKey"`
+	Slug      string    `json:"slug" gorm:"primaryKey"`
+	ProductID entity.ID `json:"product_id" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+}
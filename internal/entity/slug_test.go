@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "cafe-com-leite", Slugify("Café com Leite"))
+	assert.Equal(t, "t-shirt-xl", Slugify("  T-Shirt (XL)! "))
+	assert.Equal(t, "acao-2024", Slugify("Ação -- 2024"))
+	assert.Equal(t, "product", Slugify("!!!"))
+
+	long := Slugify(strings.Repeat("ab ", 40))
+	assert.LessOrEqual(t, len(long), 80)
+	assert.True(t, IsSlug(long))
+}
+
+func TestNumberedSlug(t *testing.T) {
+	assert.Equal(t, "blue-shirt-2", NumberedSlug("blue-shirt", 2))
+
+	long := NumberedSlug(strings.Repeat("a", 80), 12)
+	assert.Len(t, long, 80)
+	assert.True(t, IsSlug(long))
+	assert.True(t, strings.HasSuffix(long, "-12"))
+
+	// Cutting the base right after a hyphen drops the hyphen.
+	assert.True(t, IsSlug(NumberedSlug(strings.Repeat("a", 77)+"-bc", 2)))
+}
+
+func TestIsSlug(t *testing.T) {
+	assert.True(t, IsSlug("blue-shirt-2"))
+	assert.False(t, IsSlug(""))
+	assert.False(t, IsSlug("Blue-shirt"))
+	assert.False(t, IsSlug("blue--shirt"))
+	assert.False(t, IsSlug("-blue"))
+	assert.False(t, IsSlug("blue_shirt"))
+	assert.False(t, IsSlug(strings.Repeat("a", 81)))
+}
+
+func TestProductSlugAndSKU(t *testing.T) {
+	product, _ := NewProduct("Shirt", brl(1000))
+	product.Slug = "Shirt"
+	assert.Equal(t, ErrInvalidSlug, product.Validate())
+	product.Slug = "shirt"
+	assert.Nil(t, product.Validate())
+
+	sku := "  SH-001 "
+	product.SetSKU(&sku)
+	assert.Equal(t, "SH-001", *product.SKU)
+	assert.Nil(t, product.Validate())
+
+	blank := " "
+	product.SetSKU(&blank)
+	assert.Nil(t, product.SKU)
+
+	spaced := "SH 001"
+	product.SKU = &spaced
+	assert.Equal(t, ErrInvalidSKU, product.Validate())
+}
@@ -0,0 +1,114 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+const (
+	StockReceived  = "receive"
+	StockSold      = "sell"
+	StockCorrected = "correct"
+)
+
+const (
+	ReservationActive   = "active"
+	ReservationReleased = "released"
+	ReservationExpired  = "expired"
+)
+
+var (
+	ErrInvalidStockKind = errors.New("kind must be receive, sell or correct")
+	ErrInvalidQuantity  = errors.New("invalid quantity")
+	ErrInvalidTTL       = errors.New("ttl must be positive")
+)
+
+// StockMovement is an entry of the stock ledger. Quantity is the signed
+// change it made to the units on hand.
+type StockMovement struct {
+	ID        entity.ID `json:"id"`
+	ProductID entity.ID `json:"product_id" gorm:"index"`
+	Kind      string    `json:"kind"`
+	Quantity  int       `json:"quantity"`
+	Reason    string    `json:"reason"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewStockMovement turns a request to receive or sell quantity units, or to
+// correct the stock by a signed quantity, into a ledger entry.
+func NewStockMovement(productID entity.ID, kind string, quantity int, reason, userID string) (*StockMovement, error) {
+	switch kind {
+	case StockReceived:
+		if quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+	case StockSold:
+		if quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+		quantity = -quantity
+	case StockCorrected:
+		if quantity == 0 {
+			return nil, ErrInvalidQuantity
+		}
+	default:
+		return nil, ErrInvalidStockKind
+	}
+
+	return &StockMovement{
+		ID:        entity.NewId(),
+		ProductID: productID,
+		Kind:      kind,
+		Quantity:  quantity,
+		Reason:    reason,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// StockLevel is the projection of the ledger for one product. Units held by
+// active reservations count as reserved until they are released or expire.
+type StockLevel struct {
+	ProductID entity.ID `json:"product_id" gorm:"primaryKey"`
+	OnHand    int       `json:"on_hand"`
+	Reserved  int       `json:"reserved"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *StockLevel) Available() int {
+	return s.OnHand - s.Reserved
+}
+
+// Reservation holds units of a product for a while, typically during a
+// checkout, so nobody else can take them.
+type Reservation struct {
+	ID        entity.ID `json:"id"`
+	ProductID entity.ID `json:"product_id" gorm:"index"`
+	Quantity  int       `json:"quantity"`
+	Status    string    `json:"status" gorm:"index"`
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewReservation(productID entity.ID, quantity int, userID string, ttl time.Duration) (*Reservation, error) {
+	if quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if ttl <= 0 {
+		return nil, ErrInvalidTTL
+	}
+	now := time.Now()
+	return &Reservation{
+		ID:        entity.NewId(),
+		ProductID: productID,
+		Quantity:  quantity,
+		Status:    ReservationActive,
+		UserID:    userID,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, nil
+}
@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStockMovement(t *testing.T) {
+	productID := entity.NewId()
+
+	received, err := NewStockMovement(productID, StockReceived, 10, "", "user")
+	assert.Nil(t, err)
+	assert.Equal(t, 10, received.Quantity)
+
+	sold, err := NewStockMovement(productID, StockSold, 3, "", "user")
+	assert.Nil(t, err)
+	assert.Equal(t, -3, sold.Quantity)
+
+	corrected, err := NewStockMovement(productID, StockCorrected, -2, "broken", "user")
+	assert.Nil(t, err)
+	assert.Equal(t, -2, corrected.Quantity)
+	assert.Equal(t, "broken", corrected.Reason)
+}
+
+func TestStockMovementWhenQuantityIsInvalid(t *testing.T) {
+	productID := entity.NewId()
+
+	_, err := NewStockMovement(productID, StockReceived, -1, "", "user")
+	assert.Equal(t, ErrInvalidQuantity, err)
+	_, err = NewStockMovement(productID, StockSold, 0, "", "user")
+	assert.Equal(t, ErrInvalidQuantity, err)
+	_, err = NewStockMovement(productID, StockCorrected, 0, "", "user")
+	assert.Equal(t, ErrInvalidQuantity, err)
+	_, err = NewStockMovement(productID, "steal", 1, "", "user")
+	assert.Equal(t, ErrInvalidStockKind, err)
+}
+
+func TestNewReservation(t *testing.T) {
+	reservation, err := NewReservation(entity.NewId(), 2, "user", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, ReservationActive, reservation.Status)
+	assert.Equal(t, time.Minute, reservation.ExpiresAt.Sub(reservation.CreatedAt))
+
+	_, err = NewReservation(entity.NewId(), 0, "user", time.Minute)
+	assert.Equal(t, ErrInvalidQuantity, err)
+	_, err = NewReservation(entity.NewId(), 1, "user", 0)
+	assert.Equal(t, ErrInvalidTTL, err)
+}
@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrTagIsRequired = errors.New("tag must not be empty")
+	ErrDuplicateTag  = errors.New("duplicate tag")
+)
+
+// Tag is a free form label shared by products. Tags are identified by their
+// normalized name and travel in JSON as plain strings.
+type Tag struct {
+	Name string `gorm:"primaryKey"`
+}
+
+// NewTags normalizes names, drops duplicates and sorts them.
+func NewTags(names []string) []Tag {
+	seen := map[string]bool{}
+	tags := []Tag{}
+	for _, name := range names {
+		name = normalizeTag(name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		tags = append(tags, Tag{Name: name})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags
+}
+
+func (t Tag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Name)
+}
+
+func (t *Tag) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	t.Name = normalizeTag(name)
+	return nil
+}
+
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func validateTags(tags []Tag) error {
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		if tag.Name == "" {
+			return ErrTagIsRequired
+		}
+		if seen[tag.Name] {
+			return ErrDuplicateTag
+		}
+		seen[tag.Name] = true
+	}
+	return nil
+}
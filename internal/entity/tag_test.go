@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTags(t *testing.T) {
+	tags := NewTags([]string{" Sale", "red", "sale "})
+	assert.Equal(t, []Tag{{Name: "red"}, {Name: "sale"}}, tags)
+}
+
+func TestTagJSON(t *testing.T) {
+	raw, err := json.Marshal([]Tag{{Name: "red"}})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `["red"]`, string(raw))
+
+	var tags []Tag
+	assert.Nil(t, json.Unmarshal([]byte(`[" Red "]`), &tags))
+	assert.Equal(t, []Tag{{Name: "red"}}, tags)
+}
+
+func TestProductWhenTagIsInvalid(t *testing.T) {
+	p, err := NewProduct("product 1", brl(1000))
+	assert.Nil(t, err)
+
+	p.Tags = []Tag{{Name: ""}}
+	assert.Equal(t, ErrTagIsRequired, p.Validate())
+
+	p.Tags = []Tag{{Name: "red"}, {Name: "red"}}
+	assert.Equal(t, ErrDuplicateTag, p.Validate())
+}
@@ -16,6 +16,8 @@ func TestNewUser(t *testing.T) {
 	assert.NotEmpty(t, user.Password)
 	assert.Equal(t, "John Dow", user.Name)
 	assert.Equal(t, "j@j.com", user.Email)
+	assert.Equal(t, RoleUser, user.Role)
+	assert.False(t, user.IsAdmin())
 }
 
 func TestUser_ValidatePassword(t *testing.T) {
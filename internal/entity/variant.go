@@ -0,0 +1,135 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+var (
+	ErrSKUIsRequired   = errors.New("sku is required")
+	ErrOptionsRequired = errors.New("at least one option is required")
+	ErrInvalidOption   = errors.New("option names and values must not be empty")
+	ErrInvalidOptions  = errors.New("invalid variant options")
+	ErrVariantCurrency = errors.New("variant price must be in the currency of the product price")
+)
+
+// VariantOptions maps option names such as size or color to the value the
+// variant has for them. It is stored as a JSON column.
+type VariantOptions map[string]string
+
+func (o VariantOptions) Value() (driver.Value, error) {
+	raw, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+func (o *VariantOptions) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*o = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), o)
+	case []byte:
+		return json.Unmarshal(v, o)
+	}
+	return ErrInvalidOptions
+}
+
+// Key returns the options in a canonical form, such as
+// {"color":"blue","size":"m"}, which is equal for two option sets that only
+// differ in case or order.
+func (o VariantOptions) Key() string {
+	lower := make(map[string]string, len(o))
+	for name, value := range o {
+		lower[strings.ToLower(name)] = strings.ToLower(value)
+	}
+	// encoding/json writes map keys sorted.
+	raw, _ := json.Marshal(lower)
+	return string(raw)
+}
+
+// Variant is a purchasable version of a product, such as its blue M shirt.
+// A nil Price means the variant sells at the product price. Variants belong
+// to the tenant of their product and SKUs are unique within it.
+type Variant struct {
+	ID        entity.ID      `json:"id"`
+	TenantID  string         `json:"-" gorm:"uniqueIndex:idx_variants_tenant_sku,priority:1"`
+	ProductID entity.ID      `json:"product_id" gorm:"uniqueIndex:idx_variants_product_options"`
+	SKU       string         `json:"sku" gorm:"uniqueIndex:idx_variants_tenant_sku,priority:2"`
+	Price     *entity.Money  `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Options   VariantOptions `json:"options" gorm:"type:text" swaggertype:"object,string"`
+	OptionKey string         `json:"-" gorm:"uniqueIndex:idx_variants_product_options"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func NewVariant(productID entity.ID, sku string, price *entity.Money, options map[string]string) (*Variant, error) {
+	variant := &Variant{
+		ID:        entity.NewId(),
+		ProductID: productID,
+		CreatedAt: time.Now(),
+	}
+	if err := variant.Set(sku, price, options); err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+// Set replaces the SKU, price override and options of the variant. Option
+// names are lower cased and surrounding spaces are trimmed everywhere.
+func (v *Variant) Set(sku string, price *entity.Money, options map[string]string) error {
+	v.SKU = strings.TrimSpace(sku)
+	v.Price = price
+	v.Options = VariantOptions{}
+	for name, value := range options {
+		v.Options[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	v.OptionKey = v.Options.Key()
+	return v.Validate()
+}
+
+// ValidateFor checks the variant against its product: a price override
+// must be in the currency of the product price, so carts, orders and price
+// filters never mix currencies within a product.
+func (v *Variant) ValidateFor(product *Product) error {
+	if v.Price != nil && v.Price.Currency != product.Price.Currency {
+		return ErrVariantCurrency
+	}
+	return nil
+}
+
+func (v *Variant) Validate() error {
+	if v.ID.String() == "" {
+		return ErrIDIsRequired
+	}
+	if _, err := entity.ParseID(v.ID.String()); err != nil {
+		return ErrInvalidID
+	}
+	if v.SKU == "" {
+		return ErrSKUIsRequired
+	}
+	if v.Price != nil {
+		if v.Price.IsZero() || v.Price.IsNegative() {
+			return ErrInvalidPrice
+		}
+		if !entity.IsCurrency(v.Price.Currency) {
+			return entity.ErrInvalidCurrency
+		}
+	}
+	if len(v.Options) == 0 {
+		return ErrOptionsRequired
+	}
+	for name, value := range v.Options {
+		if name == "" || value == "" {
+			return ErrInvalidOption
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewVariant(t *testing.T) {
+	price := brl(2500)
+	v, err := NewVariant(entity.NewId(), " SHIRT-M-BLUE ", &price, map[string]string{"Size": "M", " color ": "blue"})
+	assert.Nil(t, err)
+	assert.Equal(t, "SHIRT-M-BLUE", v.SKU)
+	assert.Equal(t, VariantOptions{"size": "M", "color": "blue"}, v.Options)
+	assert.Equal(t, `{"color":"blue","size":"m"}`, v.OptionKey)
+}
+
+func TestVariantOptionKeyIgnoresCaseAndOrder(t *testing.T) {
+	a := VariantOptions{"size": "M", "color": "Blue"}
+	b := VariantOptions{"color": "blue", "size": "m"}
+	assert.Equal(t, a.Key(), b.Key())
+	assert.NotEqual(t, a.Key(), VariantOptions{"size": "L", "color": "blue"}.Key())
+}
+
+func TestVariantValidate(t *testing.T) {
+	productID := entity.NewId()
+
+	_, err := NewVariant(productID, "", nil, map[string]string{"size": "M"})
+	assert.Equal(t, ErrSKUIsRequired, err)
+
+	_, err = NewVariant(productID, "SKU", nil, nil)
+	assert.Equal(t, ErrOptionsRequired, err)
+
+	_, err = NewVariant(productID, "SKU", nil, map[string]string{"size": " "})
+	assert.Equal(t, ErrInvalidOption, err)
+
+	price := brl(-1)
+	_, err = NewVariant(productID, "SKU", &price, map[string]string{"size": "M"})
+	assert.Equal(t, ErrInvalidPrice, err)
+
+	v, err := NewVariant(productID, "SKU", nil, map[string]string{"size": "M"})
+	assert.Nil(t, err)
+	assert.Nil(t, v.Price)
+}
+
+func TestVariantValidateForProduct(t *testing.T) {
+	product, _ := NewProduct("Shirt", brl(2000))
+
+	inherited, _ := NewVariant(product.ID, "SKU-1", nil, map[string]string{"size": "M"})
+	assert.Nil(t, inherited.ValidateFor(product))
+
+	price := brl(2500)
+	override, _ := NewVariant(product.ID, "SKU-2", &price, map[string]string{"size": "L"})
+	assert.Nil(t, override.ValidateFor(product))
+
+	dollars := entity.Money{Amount: 500, Currency: "USD"}
+	foreign, _ := NewVariant(product.ID, "SKU-3", &dollars, map[string]string{"size": "S"})
+	assert.Equal(t, ErrVariantCurrency, foreign.ValidateFor(product))
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrCartChanged = errors.New("cart was changed by another request")
+
+type Cart struct {
+	DB     *gorm.DB
+	tenant *string
+}
+
+func NewCart(db *gorm.DB) *Cart {
+	return &Cart{DB: db}
+}
+
+// ForTenant returns a copy of the repository that only sees the carts and
+// quotes of tenantID, like Product.ForTenant.
+func (c *Cart) ForTenant(tenantID string) CartInterface {
+	return &Cart{DB: c.DB, tenant: &tenantID}
+}
+
+func (c *Cart) scope(db *gorm.DB) *gorm.DB {
+	if c.tenant == nil {
+		return db
+	}
+	return db.Where("tenant_id = ?", *c.tenant)
+}
+
+// FindItems lists the cart items of userID in the order they were added.
+func (c *Cart) FindItems(userID string) ([]*entity.CartItem, error) {
+	return c.findItems(c.DB, userID)
+}
+
+func (c *Cart) findItems(db *gorm.DB, userID string) ([]*entity.CartItem, error) {
+	var items []*entity.CartItem
+	err := c.scope(db).Where("user_id = ?", userID).
+		Order("added_at").Order("product_id").
+		Find(&items).Error
+	return items, err
+}
+
+func (c *Cart) FindItem(userID, productID string) (*entity.CartItem, error) {
+	var item entity.CartItem
+	err := c.scope(c.DB).First(&item, "user_id = ? AND product_id = ?", userID, productID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// SaveItem adds item to the cart of its user or replaces the item of the
+// same product.
+func (c *Cart) SaveItem(item *entity.CartItem) error {
+	if c.tenant != nil {
+		item.TenantID = *c.tenant
+	}
+	return c.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(item).Error
+}
+
+func (c *Cart) DeleteItem(userID, productID string) error {
+	result := c.scope(c.DB).Where("user_id = ? AND product_id = ?", userID, productID).Delete(&entity.CartItem{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// QuoteFunc prices cart items into a quote, reading products and their
+// price schedules through the repositories it is given.
+type QuoteFunc func(items []*entity.CartItem, productDB ProductInterface, priceScheduleDB PriceScheduleInterface) (*entity.Quote, error)
+
+// Checkout prices the cart of userID with quote, stores the quote and
+// removes the items it was priced from, all in one transaction, so the
+// quote is priced from the products and schedules as they are when it is
+// stored. When one of the items changes or is removed meanwhile, nothing is
+// stored and ErrCartChanged is returned. Items added meanwhile stay in the
+// cart.
+func (c *Cart) Checkout(userID string, quote QuoteFunc) (*entity.Quote, error) {
+	var stored *entity.Quote
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		items, err := c.findItems(tx, userID)
+		if err != nil {
+			return err
+		}
+		priced, err := quote(items, &Product{DB: tx, tenant: c.tenant}, NewPriceSchedule(tx))
+		if err != nil {
+			return err
+		}
+		if c.tenant != nil {
+			priced.TenantID = *c.tenant
+		}
+		if err := tx.Create(priced).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			result := c.scope(tx).
+				Where("user_id = ? AND product_id = ? AND quantity = ?", userID, item.ProductID, item.Quantity).
+				Delete(&entity.CartItem{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrCartChanged
+			}
+		}
+		stored = priced
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+func (c *Cart) FindQuote(id string) (*entity.Quote, error) {
+	var quote entity.Quote
+	err := c.scope(c.DB).Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("line")
+	}).First(&quote, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestQuote(t *testing.T, userID string, items []*entity.CartItem) *entity.Quote {
+	var lines []entity.CartLine
+	for _, item := range items {
+		line, err := entity.NewCartLine(item, "Product", &item.AddedPrice)
+		assert.NoError(t, err)
+		lines = append(lines, line)
+	}
+	cart, err := entity.NewCart(lines)
+	assert.NoError(t, err)
+	quote, err := entity.NewQuote(userID, cart)
+	assert.NoError(t, err)
+	return quote
+}
+
+func TestSaveAndDeleteCartItems(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.CartItem{})
+	cartDB := NewCart(db).ForTenant("tenant-1")
+
+	first, _ := entity.NewCartItem("user-1", entityPKG.NewId(), price(1000), 1)
+	second, _ := entity.NewCartItem("user-1", entityPKG.NewId(), price(250), 2)
+	second.AddedAt = second.AddedAt.Add(time.Second)
+	other, _ := entity.NewCartItem("user-2", entityPKG.NewId(), price(250), 2)
+	assert.NoError(t, cartDB.SaveItem(first))
+	assert.NoError(t, cartDB.SaveItem(second))
+	assert.NoError(t, cartDB.SaveItem(other))
+
+	first.Quantity = 5
+	assert.NoError(t, cartDB.SaveItem(first))
+
+	items, err := cartDB.FindItems("user-1")
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, first.ProductID, items[0].ProductID)
+	assert.Equal(t, 5, items[0].Quantity)
+	assert.Equal(t, second.ProductID, items[1].ProductID)
+
+	found, err := cartDB.FindItem("user-1", second.ProductID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, price(250), found.AddedPrice)
+	_, err = cartDB.FindItem("user-1", other.ProductID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	assert.NoError(t, cartDB.DeleteItem("user-1", first.ProductID.String()))
+	assert.ErrorIs(t, cartDB.DeleteItem("user-1", first.ProductID.String()), gorm.ErrRecordNotFound)
+	items, _ = cartDB.FindItems("user-1")
+	assert.Len(t, items, 1)
+
+	items, _ = NewCart(db).ForTenant("tenant-2").FindItems("user-1")
+	assert.Empty(t, items)
+}
+
+func TestCheckout(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.CartItem{}, &entity.Quote{}, &entity.QuoteItem{})
+	cartDB := NewCart(db).ForTenant("tenant-1")
+	testQuote := func(items []*entity.CartItem, _ ProductInterface, _ PriceScheduleInterface) (*entity.Quote, error) {
+		return newTestQuote(t, "user-1", items), nil
+	}
+
+	first, _ := entity.NewCartItem("user-1", entityPKG.NewId(), price(1000), 1)
+	second, _ := entity.NewCartItem("user-1", entityPKG.NewId(), price(250), 2)
+	assert.NoError(t, cartDB.SaveItem(first))
+	assert.NoError(t, cartDB.SaveItem(second))
+
+	// Another request changes a quantity once the cart has been priced.
+	changed := false
+	db.Callback().Delete().Before("gorm:delete").Register("test:change_cart", func(tx *gorm.DB) {
+		if changed || tx.Statement.Table != "cart_items" {
+			return
+		}
+		changed = true
+		tx.Session(&gorm.Session{NewDB: true}).Exec("UPDATE cart_items SET quantity = 3 WHERE product_id = ?", second.ProductID)
+	})
+	_, err = cartDB.Checkout("user-1", testQuote)
+	assert.ErrorIs(t, err, ErrCartChanged)
+	var count int64
+	db.Model(&entity.Quote{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	items, _ := cartDB.FindItems("user-1")
+	assert.Len(t, items, 2)
+
+	quote, err := cartDB.Checkout("user-1", testQuote)
+	assert.NoError(t, err)
+
+	items, _ = cartDB.FindItems("user-1")
+	assert.Empty(t, items)
+	found, err := cartDB.FindQuote(quote.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, price(1500), found.Total)
+	assert.Len(t, found.Items, 2)
+	assert.Equal(t, 1, found.Items[0].Line)
+
+	_, err = NewCart(db).ForTenant("tenant-2").FindQuote(quote.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategoryCycle       = errors.New("category cannot be moved below itself")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+	ErrCategoryInUse       = errors.New("category has products")
+)
+
+// maxCategoryDepth bounds the walk up the tree, so a cycle left in the table
+// cannot make categoryAncestors loop forever.
+const maxCategoryDepth = 100
+
+// categoryAncestors selects the ids of a category and of every category above
+// it, root first.
+const categoryAncestors = `WITH RECURSIVE ancestors(id, parent_id, depth) AS (
+	SELECT id, parent_id, 0 FROM categories WHERE id = ?
+	UNION ALL
+	SELECT c.id, c.parent_id, a.depth + 1 FROM categories c JOIN ancestors a ON c.id = a.parent_id WHERE a.depth < ?
+) SELECT id FROM ancestors ORDER BY depth DESC`
+
+// categorySubtree selects the ids of a category and of every category below
+// it. UNION drops rows already seen, which also stops it on cycles.
+const categorySubtree = `WITH RECURSIVE subtree(id) AS (
+	SELECT id FROM categories WHERE id = ?
+	UNION
+	SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+) SELECT id FROM subtree`
+
+type Category struct {
+	DB     *gorm.DB
+	tenant *string
+}
+
+func NewCategory(db *gorm.DB) *Category {
+	return &Category{DB: db}
+}
+
+// ForTenant returns a copy of the repository that only sees the categories
+// of tenantID, like Product.ForTenant.
+func (c *Category) ForTenant(tenantID string) CategoryInterface {
+	return &Category{DB: c.DB, tenant: &tenantID}
+}
+
+func (c *Category) scope(db *gorm.DB) *gorm.DB {
+	if c.tenant == nil {
+		return db
+	}
+	return db.Where("tenant_id = ?", *c.tenant)
+}
+
+func (c *Category) Create(category *entity.Category) error {
+	if c.tenant != nil {
+		category.TenantID = *c.tenant
+	}
+	return c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkCategory(tx, category.TenantID, category.ParentID); err != nil {
+			return err
+		}
+		return tx.Create(category).Error
+	})
+}
+
+// FindAll lists categories by name. A nil parentID lists every category.
+func (c *Category) FindAll(parentID *string) ([]*entity.Category, error) {
+	var categories []*entity.Category
+	query := c.scope(c.DB).Order("name").Order("id")
+	if parentID != nil {
+		if *parentID == "" {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id = ?", *parentID)
+		}
+	}
+	err := query.Find(&categories).Error
+	return categories, err
+}
+
+func (c *Category) FindById(id string) (*entity.Category, error) {
+	var category entity.Category
+	if err := c.scope(c.DB).First(&category, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// Update saves category, refusing to move it below one of its own
+// descendants.
+func (c *Category) Update(category *entity.Category) error {
+	return c.DB.Transaction(func(tx *gorm.DB) error {
+		var stored entity.Category
+		if err := c.scope(tx).First(&stored, "id = ?", category.ID).Error; err != nil {
+			return err
+		}
+		category.TenantID = stored.TenantID
+		if err := checkCategory(tx, stored.TenantID, category.ParentID); err != nil {
+			return err
+		}
+		if category.ParentID != nil {
+			ancestors, err := findAncestorIDs(tx, category.ParentID.String())
+			if err != nil {
+				return err
+			}
+			for _, id := range ancestors {
+				if id == category.ID.String() {
+					return ErrCategoryCycle
+				}
+			}
+		}
+
+		result := c.scope(tx.Model(category)).Select("Name", "ParentID").Updates(category)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// Delete removes a category that has neither subcategories nor products,
+// including products in the trash.
+func (c *Category) Delete(id string) error {
+	return c.DB.Transaction(func(tx *gorm.DB) error {
+		if err := c.scope(tx).First(&entity.Category{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&entity.Category{}).Where("parent_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCategoryHasChildren
+		}
+		if err := tx.Unscoped().Model(&entity.Product{}).Where("category_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCategoryInUse
+		}
+
+		result := c.scope(tx).Delete(&entity.Category{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// FindBreadcrumb returns the path from the root category down to id.
+func (c *Category) FindBreadcrumb(id string) ([]*entity.Category, error) {
+	if _, err := c.FindById(id); err != nil {
+		return nil, err
+	}
+	ids, err := findAncestorIDs(c.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var categories []*entity.Category
+	if err := c.scope(c.DB).Where("id IN ?", ids).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	byID := map[string]*entity.Category{}
+	for _, category := range categories {
+		byID[category.ID.String()] = category
+	}
+	breadcrumb := make([]*entity.Category, 0, len(ids))
+	for _, id := range ids {
+		breadcrumb = append(breadcrumb, byID[id])
+	}
+	return breadcrumb, nil
+}
+
+func findAncestorIDs(db *gorm.DB, id string) ([]string, error) {
+	var ids []string
+	err := db.Raw(categoryAncestors, id, maxCategoryDepth).Scan(&ids).Error
+	return ids, err
+}
+
+// checkCategory makes sure a referenced category exists in tenantID. A nil
+// id is a valid reference to no category.
+func checkCategory(db *gorm.DB, tenantID string, id *entityPKG.ID) error {
+	if id == nil {
+		return nil
+	}
+	var count int64
+	err := db.Model(&entity.Category{}).Where("id = ? AND tenant_id = ?", id.String(), tenantID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
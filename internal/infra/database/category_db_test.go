@@ -0,0 +1,145 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCategoryBreadcrumb(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Category{})
+	categoryDB := NewCategory(db)
+
+	electronics, _ := entity.NewCategory("Electronics", nil)
+	phones, _ := entity.NewCategory("Phones", &electronics.ID)
+	android, _ := entity.NewCategory("Android", &phones.ID)
+	for _, category := range []*entity.Category{electronics, phones, android} {
+		assert.NoError(t, categoryDB.Create(category))
+	}
+
+	breadcrumb, err := categoryDB.FindBreadcrumb(android.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, breadcrumb, 3)
+	assert.Equal(t, "Electronics", breadcrumb[0].Name)
+	assert.Equal(t, "Phones", breadcrumb[1].Name)
+	assert.Equal(t, "Android", breadcrumb[2].Name)
+
+	_, err = categoryDB.FindBreadcrumb(entity.Category{}.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestCreateCategoryWithUnknownParent(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Category{})
+
+	parent, _ := entity.NewCategory("Electronics", nil)
+	category, _ := entity.NewCategory("Phones", &parent.ID)
+	err = NewCategory(db).Create(category)
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+}
+
+func TestUpdateCategoryRejectsCycles(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Category{})
+	categoryDB := NewCategory(db)
+
+	electronics, _ := entity.NewCategory("Electronics", nil)
+	phones, _ := entity.NewCategory("Phones", &electronics.ID)
+	assert.NoError(t, categoryDB.Create(electronics))
+	assert.NoError(t, categoryDB.Create(phones))
+
+	electronics.ParentID = &phones.ID
+	assert.ErrorIs(t, categoryDB.Update(electronics), ErrCategoryCycle)
+
+	phones.ParentID = nil
+	phones.Name = "Mobile"
+	assert.NoError(t, categoryDB.Update(phones))
+	found, err := categoryDB.FindById(phones.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Mobile", found.Name)
+	assert.Nil(t, found.ParentID)
+}
+
+func TestDeleteCategory(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Category{}, &entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.Variant{}, &entity.PriceSchedule{}, &entity.ProductImage{}, &entity.Review{},
+		&entity.StockLevel{}, &entity.StockMovement{}, &entity.Reservation{}, &entity.CartItem{})
+	categoryDB := NewCategory(db)
+
+	electronics, _ := entity.NewCategory("Electronics", nil)
+	phones, _ := entity.NewCategory("Phones", &electronics.ID)
+	assert.NoError(t, categoryDB.Create(electronics))
+	assert.NoError(t, categoryDB.Create(phones))
+
+	product, _ := entity.NewProduct("Phone", price(1000))
+	product.CategoryID = &phones.ID
+	assert.NoError(t, NewProduct(db).Create(product))
+
+	assert.ErrorIs(t, categoryDB.Delete(electronics.ID.String()), ErrCategoryHasChildren)
+	assert.ErrorIs(t, categoryDB.Delete(phones.ID.String()), ErrCategoryInUse)
+
+	assert.NoError(t, NewProduct(db).Delete(product.ID.String(), product.Version))
+	assert.NoError(t, NewProduct(db).Purge(product.ID.String()))
+	assert.NoError(t, categoryDB.Delete(phones.ID.String()))
+	assert.NoError(t, categoryDB.Delete(electronics.ID.String()))
+}
+
+func TestCategoryTenantIsolation(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Category{}, &entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	tenantA := NewCategory(db).ForTenant("tenant-a")
+	tenantB := NewCategory(db).ForTenant("tenant-b")
+
+	electronics, _ := entity.NewCategory("Electronics", nil)
+	assert.NoError(t, tenantA.Create(electronics))
+	assert.Equal(t, "tenant-a", electronics.TenantID)
+	books, _ := entity.NewCategory("Books", nil)
+	assert.NoError(t, tenantB.Create(books))
+	idA := electronics.ID.String()
+
+	categories, err := tenantB.FindAll(nil)
+	assert.NoError(t, err)
+	assert.Len(t, categories, 1)
+	assert.Equal(t, books.ID, categories[0].ID)
+
+	// Knowing the ID of a category of another tenant gives no access to it.
+	_, err = tenantB.FindById(idA)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = tenantB.FindBreadcrumb(idA)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	stolen := *electronics
+	stolen.Name = "Stolen"
+	assert.ErrorIs(t, tenantB.Update(&stolen), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, tenantB.Delete(idA), gorm.ErrRecordNotFound)
+
+	phones, _ := entity.NewCategory("Phones", &electronics.ID)
+	assert.ErrorIs(t, tenantB.Create(phones), ErrCategoryNotFound)
+	books.ParentID = &electronics.ID
+	assert.ErrorIs(t, tenantB.Update(books), ErrCategoryNotFound)
+	product, _ := entity.NewProduct("Phone", price(1000))
+	product.CategoryID = &electronics.ID
+	assert.ErrorIs(t, NewProduct(db).ForTenant("tenant-b").Create(product), ErrCategoryNotFound)
+
+	found, err := tenantA.FindById(idA)
+	assert.NoError(t, err)
+	assert.Equal(t, "Electronics", found.Name)
+}
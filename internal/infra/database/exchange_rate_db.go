@@ -0,0 +1,81 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExchangeRate struct {
+	DB *gorm.DB
+}
+
+func NewExchangeRate(db *gorm.DB) *ExchangeRate {
+	return &ExchangeRate{DB: db}
+}
+
+// Save stores rates in a single transaction. A rate for a pair and date that
+// already exists is overwritten.
+func (e *ExchangeRate) Save(rates []*entity.ExchangeRate) error {
+	return e.DB.Transaction(func(tx *gorm.DB) error {
+		for _, rate := range rates {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "from_currency"}, {Name: "to_currency"}, {Name: "rate_date"}},
+				DoUpdates: clause.AssignmentColumns([]string{"rate"}),
+			}).Create(rate).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindAll lists rates, newest first. Empty currencies match any currency.
+func (e *ExchangeRate) FindAll(from, to string) ([]*entity.ExchangeRate, error) {
+	var rates []*entity.ExchangeRate
+	query := e.DB.Order("rate_date desc").Order("from_currency").Order("to_currency")
+	if from != "" {
+		query = query.Where("from_currency = ?", from)
+	}
+	if to != "" {
+		query = query.Where("to_currency = ?", to)
+	}
+	err := query.Find(&rates).Error
+	return rates, err
+}
+
+// FindLatest returns the most recent rate converting from into to dated no
+// later than at. When only the opposite pair was uploaded its inverse is
+// returned.
+func (e *ExchangeRate) FindLatest(from, to string, at time.Time) (*entity.ExchangeRate, error) {
+	rate, err := e.findLatest(from, to, at)
+	if err == nil {
+		return rate, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	rate, err = e.findLatest(to, from, at)
+	if err != nil {
+		return nil, err
+	}
+	return rate.Inverse()
+}
+
+func (e *ExchangeRate) findLatest(from, to string, at time.Time) (*entity.ExchangeRate, error) {
+	var rate entity.ExchangeRate
+	err := e.DB.
+		Where("from_currency = ? AND to_currency = ?", from, to).
+		Where("rate_date <= ?", at.UTC()).
+		Order("rate_date desc").
+		First(&rate).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
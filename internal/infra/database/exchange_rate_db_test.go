@@ -0,0 +1,61 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSaveExchangeRates(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.ExchangeRate{})
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	first, _ := entity.NewExchangeRate("USD", "BRL", "5.10", day)
+	second, _ := entity.NewExchangeRate("USD", "BRL", "5.20", day)
+
+	exchangeRateDB := NewExchangeRate(db)
+	assert.NoError(t, exchangeRateDB.Save([]*entity.ExchangeRate{first}))
+	assert.NoError(t, exchangeRateDB.Save([]*entity.ExchangeRate{second}))
+
+	rates, err := exchangeRateDB.FindAll("USD", "")
+	assert.NoError(t, err)
+	assert.Len(t, rates, 1)
+	assert.Equal(t, "5.20", rates[0].Rate)
+}
+
+func TestFindLatestExchangeRate(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.ExchangeRate{})
+
+	may1 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	may2 := may1.AddDate(0, 0, 1)
+	older, _ := entity.NewExchangeRate("USD", "BRL", "5.00", may1)
+	newer, _ := entity.NewExchangeRate("USD", "BRL", "4.00", may2)
+
+	exchangeRateDB := NewExchangeRate(db)
+	assert.NoError(t, exchangeRateDB.Save([]*entity.ExchangeRate{older, newer}))
+
+	rate, err := exchangeRateDB.FindLatest("USD", "BRL", may1.Add(12*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, "5.00", rate.Rate)
+
+	rate, err = exchangeRateDB.FindLatest("BRL", "USD", may2.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, "BRL", rate.From)
+	assert.Equal(t, "0.2500000000", rate.Rate)
+	assert.True(t, may2.Equal(rate.Date))
+
+	_, err = exchangeRateDB.FindLatest("USD", "EUR", may2)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
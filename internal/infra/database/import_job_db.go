@@ -0,0 +1,47 @@
+package database
+
+import (
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+type ImportJob struct {
+	DB *gorm.DB
+}
+
+func NewImportJob(db *gorm.DB) *ImportJob {
+	return &ImportJob{DB: db}
+}
+
+func (i *ImportJob) Create(job *entity.ImportJob) error {
+	return i.DB.Create(job).Error
+}
+
+// Update saves the status, counters and report of job.
+func (i *ImportJob) Update(job *entity.ImportJob) error {
+	return i.DB.Save(job).Error
+}
+
+func (i *ImportJob) FindById(id string) (*entity.ImportJob, error) {
+	var job entity.ImportJob
+	if err := i.DB.First(&job, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FailUnfinished fails every job that is still pending or running. Jobs run
+// in the process that accepted them, so after a restart nothing will finish
+// them.
+func (i *ImportJob) FailUnfinished(reason string, now time.Time) (int64, error) {
+	result := i.DB.Model(&entity.ImportJob{}).
+		Where("status IN ?", []string{entity.ImportPending, entity.ImportRunning}).
+		Updates(map[string]interface{}{
+			"status":      entity.ImportFailed,
+			"error":       reason,
+			"finished_at": now,
+		})
+	return result.RowsAffected, result.Error
+}
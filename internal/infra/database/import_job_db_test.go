@@ -0,0 +1,70 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestImportJobReport(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.ImportJob{})
+	jobDB := NewImportJob(db)
+
+	job, _ := entity.NewImportJob("user", entity.ImportCSV, false, 2)
+	assert.NoError(t, jobDB.Create(job))
+
+	job.Start()
+	job.Record(entity.ImportRowResult{Row: 1, Status: entity.ImportRowCreated, ProductID: &job.ID})
+	job.Record(entity.ImportRowResult{Row: 2, Status: entity.ImportRowRejected, Error: "name is required"})
+	job.Finish(time.Now())
+	assert.NoError(t, jobDB.Update(job))
+
+	found, err := jobDB.FindById(job.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.ImportCompleted, found.Status)
+	assert.Equal(t, 2, found.Processed)
+	assert.Equal(t, 1, found.Created)
+	assert.Equal(t, 1, found.Rejected)
+	assert.Equal(t, job.Rows, found.Rows)
+	assert.NotNil(t, found.FinishedAt)
+
+	_, err = jobDB.FindById(entityPKG.NewId().String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestFailUnfinishedImportJobs(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.ImportJob{})
+	jobDB := NewImportJob(db)
+
+	pending, _ := entity.NewImportJob("user", entity.ImportCSV, false, 1)
+	running, _ := entity.NewImportJob("user", entity.ImportCSV, false, 1)
+	running.Start()
+	done, _ := entity.NewImportJob("user", entity.ImportCSV, false, 1)
+	done.Finish(time.Now())
+	for _, job := range []*entity.ImportJob{pending, running, done} {
+		assert.NoError(t, jobDB.Create(job))
+	}
+
+	count, err := jobDB.FailUnfinished("server restarted", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	found, _ := jobDB.FindById(running.ID.String())
+	assert.Equal(t, entity.ImportFailed, found.Status)
+	assert.Equal(t, "server restarted", found.Error)
+	found, _ = jobDB.FindById(done.ID.String())
+	assert.Equal(t, entity.ImportCompleted, found.Status)
+}
@@ -1,16 +1,129 @@
 package database
 
-import "github.com/FreitasGabriel/fullcycle-api/internal/entity"
+import (
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
 
 type UserInterface interface {
 	Create(user *entity.User) error
 	FindByEmail(email string) (*entity.User, error)
 }
 
+type OrganizationInterface interface {
+	Create(organization *entity.Organization, ownerID entityPKG.ID) error
+	FindByUser(userID string) ([]*entity.Organization, error)
+	AddMember(membership *entity.Membership) error
+}
+
 type ProductInterface interface {
+	WithActor(userID string) ProductInterface
+	ForTenant(tenantID string) ProductInterface
+	Transaction(fn func(tx ProductInterface) error) error
 	Create(product *entity.Product) error
-	FindAll(page, limit int, sort string) ([]*entity.Product, error)
+	FindAll(page, limit int, sort ProductSort, filter ProductFilter) ([]*entity.Product, error)
+	FindAllAfter(cursor *ProductCursor, limit int, sort ProductSort, filter ProductFilter) ([]*entity.Product, *ProductCursor, error)
+	Count(filter ProductFilter) (int64, error)
 	FindById(id string) (*entity.Product, error)
+	FindBySlug(slug string) (*entity.Product, error)
+	FindBySKU(sku string) (*entity.Product, error)
+	FindSlugRedirect(slug string) (*entity.Product, error)
+	GenerateMissingSlugs() (int64, error)
+	BackfillPublishedAt() (int64, error)
 	Update(product *entity.Product) error
+	UpdateFields(product *entity.Product, fields []string) error
+	Delete(id string, version int) error
+	FindDeleted(page, limit int) ([]*entity.Product, error)
+	FindDeletedById(id string) (*entity.Product, error)
+	CountDeleted() (int64, error)
+	Restore(id string) error
+	Purge(id string) error
+	PurgeDeletedBefore(t time.Time) (int64, error)
+	FindHistory(id string) ([]*entity.ProductHistory, error)
+}
+
+type OrderInterface interface {
+	ForTenant(tenantID string) OrderInterface
+	Create(order *entity.Order) error
+	FindById(id string) (*entity.Order, error)
+	FindAll(page, limit int, filter OrderFilter) ([]*entity.Order, error)
+	Count(filter OrderFilter) (int64, error)
+	UpdateStatus(order *entity.Order, from string) error
+}
+
+type CartInterface interface {
+	ForTenant(tenantID string) CartInterface
+	FindItems(userID string) ([]*entity.CartItem, error)
+	FindItem(userID, productID string) (*entity.CartItem, error)
+	SaveItem(item *entity.CartItem) error
+	DeleteItem(userID, productID string) error
+	Checkout(userID string, quote QuoteFunc) (*entity.Quote, error)
+	FindQuote(id string) (*entity.Quote, error)
+}
+
+type CategoryInterface interface {
+	ForTenant(tenantID string) CategoryInterface
+	Create(category *entity.Category) error
+	FindAll(parentID *string) ([]*entity.Category, error)
+	FindById(id string) (*entity.Category, error)
+	Update(category *entity.Category) error
 	Delete(id string) error
+	FindBreadcrumb(id string) ([]*entity.Category, error)
+}
+
+type VariantInterface interface {
+	Create(variant *entity.Variant) error
+	FindByProduct(productID string) ([]*entity.Variant, error)
+	FindById(productID, id string) (*entity.Variant, error)
+	Update(variant *entity.Variant) error
+	Delete(productID, id string) error
+}
+
+type ProductImageInterface interface {
+	Create(image *entity.ProductImage) error
+	FindByProduct(productID string) ([]*entity.ProductImage, error)
+	FindById(productID, id string) (*entity.ProductImage, error)
+	SetPrimary(productID, id string) error
+	Reorder(productID string, ids []string) error
+	Delete(productID, id string) error
+}
+
+type ReviewInterface interface {
+	Create(review *entity.Review) error
+	FindByProduct(productID, status string) ([]*entity.Review, error)
+	FindById(productID, id string) (*entity.Review, error)
+	Update(review *entity.Review) error
+	Delete(productID, id string) error
+}
+
+type PriceScheduleInterface interface {
+	Create(schedule *entity.PriceSchedule) error
+	FindByProduct(productID string) ([]*entity.PriceSchedule, error)
+	FindActive(productIDs []string, t time.Time) (map[string]*entity.PriceSchedule, error)
+	Delete(productID, id string, now time.Time) error
+}
+
+type StockInterface interface {
+	Adjust(movement *entity.StockMovement) error
+	FindLevel(productID string) (*entity.StockLevel, error)
+	FindMovements(productID string) ([]*entity.StockMovement, error)
+	Reserve(reservation *entity.Reservation) error
+	FindReservation(productID, reservationID string) (*entity.Reservation, error)
+	Release(productID, reservationID string) error
+	ExpireReservations(now time.Time) (int64, error)
+}
+
+type ImportJobInterface interface {
+	Create(job *entity.ImportJob) error
+	Update(job *entity.ImportJob) error
+	FindById(id string) (*entity.ImportJob, error)
+	FailUnfinished(reason string, now time.Time) (int64, error)
+}
+
+type ExchangeRateInterface interface {
+	Save(rates []*entity.ExchangeRate) error
+	FindAll(from, to string) ([]*entity.ExchangeRate, error)
+	FindLatest(from, to string, at time.Time) (*entity.ExchangeRate, error)
 }
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+var ErrOrderStatusConflict = errors.New("order status was changed by another request")
+
+// OrderFilter narrows order listings. Empty fields match every order.
+type OrderFilter struct {
+	UserID string
+	Status string
+}
+
+func (f OrderFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.UserID != "" {
+		db = db.Where("user_id = ?", f.UserID)
+	}
+	if f.Status != "" {
+		db = db.Where("status = ?", f.Status)
+	}
+	return db
+}
+
+type Order struct {
+	DB     *gorm.DB
+	tenant *string
+}
+
+func NewOrder(db *gorm.DB) *Order {
+	return &Order{DB: db}
+}
+
+// ForTenant returns a copy of the repository that only sees the orders of
+// tenantID and places new orders in it, like Product.ForTenant.
+func (o *Order) ForTenant(tenantID string) OrderInterface {
+	return &Order{DB: o.DB, tenant: &tenantID}
+}
+
+func (o *Order) scope(db *gorm.DB) *gorm.DB {
+	if o.tenant == nil {
+		return db
+	}
+	return db.Where("tenant_id = ?", *o.tenant)
+}
+
+// Create stores order with its items.
+func (o *Order) Create(order *entity.Order) error {
+	if o.tenant != nil {
+		order.TenantID = *o.tenant
+	}
+	return o.DB.Create(order).Error
+}
+
+func (o *Order) FindById(id string) (*entity.Order, error) {
+	var order entity.Order
+	if err := preloadItems(o.scope(o.DB)).First(&order, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// FindAll lists the orders matching filter, most recent first.
+func (o *Order) FindAll(page, limit int, filter OrderFilter) ([]*entity.Order, error) {
+	var orders []*entity.Order
+	query := filter.apply(preloadItems(o.scope(o.DB))).Order("created_at desc").Order("id asc")
+	if page != 0 && limit != 0 {
+		query = query.Limit(limit).Offset((page - 1) * limit)
+	}
+	err := query.Find(&orders).Error
+	return orders, err
+}
+
+func (o *Order) Count(filter OrderFilter) (int64, error) {
+	var total int64
+	err := filter.apply(o.scope(o.DB.Model(&entity.Order{}))).Count(&total).Error
+	return total, err
+}
+
+// UpdateStatus saves the status of order as long as it is still from, so
+// two concurrent transitions cannot both succeed.
+func (o *Order) UpdateStatus(order *entity.Order, from string) error {
+	result := o.scope(o.DB.Model(order)).
+		Where("status = ?", from).
+		Updates(map[string]interface{}{"status": order.Status, "updated_at": order.UpdatedAt})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrderStatusConflict
+	}
+	return nil
+}
+
+// preloadItems loads the items of the orders found by db, by line.
+func preloadItems(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("line")
+	})
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestOrder(t *testing.T, userID string, prices ...int64) *entity.Order {
+	var items []*entity.OrderItem
+	for _, cents := range prices {
+		item, err := entity.NewOrderItem(entityPKG.NewId(), "Product", price(cents), 2)
+		assert.NoError(t, err)
+		items = append(items, item)
+	}
+	order, err := entity.NewOrder(userID, items)
+	assert.NoError(t, err)
+	return order
+}
+
+func TestCreateAndFindOrder(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Order{}, &entity.OrderItem{})
+	orderDB := NewOrder(db)
+
+	order := newTestOrder(t, "user-1", 1000, 250, 75)
+	assert.NoError(t, orderDB.Create(order))
+
+	found, err := orderDB.FindById(order.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", found.UserID)
+	assert.Equal(t, entity.OrderPending, found.Status)
+	assert.Equal(t, price(2650), found.Total)
+	assert.Len(t, found.Items, 3)
+	for i, item := range found.Items {
+		assert.Equal(t, i+1, item.Line)
+		assert.Equal(t, order.Items[i].ProductID, item.ProductID)
+	}
+	assert.Equal(t, price(150), found.Items[2].Total)
+
+	_, err = orderDB.FindById(entityPKG.NewId().String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestFindAllOrders(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Order{}, &entity.OrderItem{})
+	orderDB := NewOrder(db)
+	for i, userID := range []string{"user-1", "user-2", "user-1"} {
+		order := newTestOrder(t, userID, 1000)
+		order.CreatedAt = order.CreatedAt.Add(time.Duration(i) * time.Minute)
+		assert.NoError(t, orderDB.Create(order))
+	}
+
+	orders, err := orderDB.FindAll(1, 10, OrderFilter{UserID: "user-1"})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 2)
+	assert.True(t, orders[0].CreatedAt.After(orders[1].CreatedAt))
+	assert.Len(t, orders[0].Items, 1)
+
+	total, err := orderDB.Count(OrderFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+
+	orders[0].Transition(entity.OrderPaid, time.Now())
+	assert.NoError(t, orderDB.UpdateStatus(orders[0], entity.OrderPending))
+	total, err = orderDB.Count(OrderFilter{Status: entity.OrderPaid})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
+
+func TestUpdateOrderStatusConflict(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Order{}, &entity.OrderItem{})
+	orderDB := NewOrder(db)
+	order := newTestOrder(t, "user-1", 1000)
+	assert.NoError(t, orderDB.Create(order))
+
+	first, _ := orderDB.FindById(order.ID.String())
+	second, _ := orderDB.FindById(order.ID.String())
+	assert.NoError(t, first.Transition(entity.OrderPaid, time.Now()))
+	assert.NoError(t, orderDB.UpdateStatus(first, entity.OrderPending))
+	assert.NoError(t, second.Transition(entity.OrderCancelled, time.Now()))
+	assert.Equal(t, ErrOrderStatusConflict, orderDB.UpdateStatus(second, entity.OrderPending))
+
+	found, err := orderDB.FindById(order.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.OrderPaid, found.Status)
+}
+
+func TestOrderTenantIsolation(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Order{}, &entity.OrderItem{})
+	tenantA := NewOrder(db).ForTenant("tenant-a")
+	tenantB := NewOrder(db).ForTenant("tenant-b")
+	order := newTestOrder(t, "user-1", 1000)
+	assert.NoError(t, tenantA.Create(order))
+
+	_, err = tenantB.FindById(order.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	orders, err := tenantB.FindAll(0, 0, OrderFilter{})
+	assert.NoError(t, err)
+	assert.Empty(t, orders)
+	assert.NoError(t, order.Transition(entity.OrderCancelled, time.Now()))
+	assert.Equal(t, ErrOrderStatusConflict, tenantB.UpdateStatus(order, entity.OrderPending))
+
+	found, err := tenantA.FindById(order.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.OrderPending, found.Status)
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"gorm.io/gorm"
+)
+
+var ErrAlreadyMember = errors.New("user is already a member of the organization")
+
+type Organization struct {
+	DB *gorm.DB
+}
+
+func NewOrganization(db *gorm.DB) *Organization {
+	return &Organization{DB: db}
+}
+
+// Create stores organization and makes ownerID its first member.
+func (o *Organization) Create(organization *entity.Organization, ownerID entityPKG.ID) error {
+	return o.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(organization).Error; err != nil {
+			return err
+		}
+		return tx.Create(entity.NewMembership(ownerID, organization.ID)).Error
+	})
+}
+
+// FindByUser lists the organizations userID is a member of, by name.
+func (o *Organization) FindByUser(userID string) ([]*entity.Organization, error) {
+	var organizations []*entity.Organization
+	err := o.DB.
+		Where("id IN (SELECT organization_id FROM memberships WHERE user_id = ?)", userID).
+		Order("name").Order("id").
+		Find(&organizations).Error
+	return organizations, err
+}
+
+// AddMember adds a user to an existing organization.
+func (o *Organization) AddMember(membership *entity.Membership) error {
+	return o.DB.Transaction(func(tx *gorm.DB) error {
+		var organization entity.Organization
+		if err := tx.First(&organization, "id = ?", membership.OrganizationID).Error; err != nil {
+			return err
+		}
+		var count int64
+		err := tx.Model(&entity.Membership{}).
+			Where("user_id = ? AND organization_id = ?", membership.UserID, membership.OrganizationID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrAlreadyMember
+		}
+		return tx.Create(membership).Error
+	})
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestOrganizationMemberships(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Organization{}, &entity.Membership{})
+	organizationDB := NewOrganization(db)
+	alice, bob := entityPKG.NewId(), entityPKG.NewId()
+
+	brandB, _ := entity.NewOrganization("Brand B")
+	brandA, _ := entity.NewOrganization("Brand A")
+	assert.NoError(t, organizationDB.Create(brandB, alice))
+	assert.NoError(t, organizationDB.Create(brandA, alice))
+
+	organizations, err := organizationDB.FindByUser(alice.String())
+	assert.NoError(t, err)
+	assert.Len(t, organizations, 2)
+	assert.Equal(t, "Brand A", organizations[0].Name)
+	assert.Equal(t, "Brand B", organizations[1].Name)
+
+	organizations, err = organizationDB.FindByUser(bob.String())
+	assert.NoError(t, err)
+	assert.Empty(t, organizations)
+
+	assert.NoError(t, organizationDB.AddMember(entity.NewMembership(bob, brandB.ID)))
+	err = organizationDB.AddMember(entity.NewMembership(bob, brandB.ID))
+	assert.Equal(t, ErrAlreadyMember, err)
+	err = organizationDB.AddMember(entity.NewMembership(bob, entityPKG.NewId()))
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	organizations, err = organizationDB.FindByUser(bob.String())
+	assert.NoError(t, err)
+	assert.Len(t, organizations, 1)
+	assert.Equal(t, brandB.ID, organizations[0].ID)
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+var ErrPriceScheduleStarted = errors.New("price schedule already started and is part of the price history")
+
+type PriceSchedule struct {
+	DB *gorm.DB
+}
+
+func NewPriceSchedule(db *gorm.DB) *PriceSchedule {
+	return &PriceSchedule{DB: db}
+}
+
+// Create adds schedule to its product, rejecting it when it is in another
+// currency than the product price or overlaps one of the schedules the
+// product already has. The window is stored in the local zone, since sqlite
+// compares it as text with the times FindActive is given.
+func (p *PriceSchedule) Create(schedule *entity.PriceSchedule) error {
+	schedule.EffectiveFrom = schedule.EffectiveFrom.Local()
+	if schedule.EffectiveTo != nil {
+		to := schedule.EffectiveTo.Local()
+		schedule.EffectiveTo = &to
+	}
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		product, err := findProduct(tx, schedule.ProductID.String())
+		if err != nil {
+			return err
+		}
+		if schedule.Price.Currency != product.Price.Currency {
+			return entity.ErrPriceScheduleCurrency
+		}
+		var schedules []*entity.PriceSchedule
+		if err := tx.Where("product_id = ?", schedule.ProductID).Find(&schedules).Error; err != nil {
+			return err
+		}
+		if err := entity.ValidatePriceSchedules(append(schedules, schedule)); err != nil {
+			return err
+		}
+		return tx.Create(schedule).Error
+	})
+}
+
+// FindByProduct lists the schedules of a product by start time.
+func (p *PriceSchedule) FindByProduct(productID string) ([]*entity.PriceSchedule, error) {
+	var schedules []*entity.PriceSchedule
+	err := p.DB.Where("product_id = ?", productID).Order("effective_from asc").Find(&schedules).Error
+	return schedules, err
+}
+
+// FindActive returns the schedules that apply at t to the given products,
+// by product id. Products without one are left out.
+func (p *PriceSchedule) FindActive(productIDs []string, t time.Time) (map[string]*entity.PriceSchedule, error) {
+	active := map[string]*entity.PriceSchedule{}
+	if len(productIDs) == 0 {
+		return active, nil
+	}
+
+	var schedules []*entity.PriceSchedule
+	t = t.Local()
+	err := p.DB.
+		Where("product_id IN ?", productIDs).
+		Where("effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", t, t).
+		Find(&schedules).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, schedule := range schedules {
+		active[schedule.ProductID.String()] = schedule
+	}
+	return active, nil
+}
+
+// Delete removes a schedule that has not started yet. Schedules that
+// started are kept, since they record what the product cost.
+func (p *PriceSchedule) Delete(productID, id string, now time.Time) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		var schedule entity.PriceSchedule
+		if err := tx.First(&schedule, "id = ? AND product_id = ?", id, productID).Error; err != nil {
+			return err
+		}
+		if !now.Before(schedule.EffectiveFrom) {
+			return ErrPriceScheduleStarted
+		}
+		return tx.Delete(&schedule).Error
+	})
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCreatePriceSchedules(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.PriceSchedule{})
+	product, _ := entity.NewProduct("Shirt", price(1000))
+	assert.NoError(t, NewProduct(db).Create(product))
+	scheduleDB := NewPriceSchedule(db)
+
+	now := time.Now()
+	weekEnd := now.Add(7 * 24 * time.Hour)
+	sale, err := entity.NewPriceSchedule(product.ID, price(800), now, &weekEnd)
+	assert.NoError(t, err)
+	assert.NoError(t, scheduleDB.Create(sale))
+
+	overlapping, _ := entity.NewPriceSchedule(product.ID, price(700), now.Add(time.Hour), nil)
+	assert.ErrorIs(t, scheduleDB.Create(overlapping), entity.ErrOverlappingPriceSchedule)
+
+	dollars, _ := entity.NewPriceSchedule(product.ID, entityPKG.Money{Amount: 150, Currency: "USD"}, weekEnd, nil)
+	assert.ErrorIs(t, scheduleDB.Create(dollars), entity.ErrPriceScheduleCurrency)
+
+	after, _ := entity.NewPriceSchedule(product.ID, price(900), weekEnd, nil)
+	assert.NoError(t, scheduleDB.Create(after))
+
+	orphan, _ := entity.NewPriceSchedule(entityPKG.NewId(), price(900), now, nil)
+	assert.ErrorIs(t, scheduleDB.Create(orphan), gorm.ErrRecordNotFound)
+
+	schedules, err := scheduleDB.FindByProduct(product.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, schedules, 2)
+	assert.Equal(t, sale.ID, schedules[0].ID)
+	assert.Equal(t, after.ID, schedules[1].ID)
+}
+
+func TestFindActivePriceSchedules(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.PriceSchedule{})
+	productDB := NewProduct(db)
+	scheduleDB := NewPriceSchedule(db)
+	onSale, _ := entity.NewProduct("On sale", price(1000))
+	upcoming, _ := entity.NewProduct("Upcoming", price(1000))
+	regular, _ := entity.NewProduct("Regular", price(1000))
+	for _, product := range []*entity.Product{onSale, upcoming, regular} {
+		assert.NoError(t, productDB.Create(product))
+	}
+
+	now := time.Now()
+	end := now.Add(time.Hour)
+	sale, _ := entity.NewPriceSchedule(onSale.ID, price(800), now.Add(-time.Hour), &end)
+	later, _ := entity.NewPriceSchedule(upcoming.ID, price(800), end, nil)
+	assert.NoError(t, scheduleDB.Create(sale))
+	assert.NoError(t, scheduleDB.Create(later))
+
+	active, err := scheduleDB.FindActive([]string{onSale.ID.String(), upcoming.ID.String(), regular.ID.String()}, now)
+	assert.NoError(t, err)
+	assert.Len(t, active, 1)
+	assert.Equal(t, sale.ID, active[onSale.ID.String()].ID)
+
+	active, err = scheduleDB.FindActive([]string{onSale.ID.String(), upcoming.ID.String()}, end)
+	assert.NoError(t, err)
+	assert.Len(t, active, 1)
+	assert.Equal(t, later.ID, active[upcoming.ID.String()].ID)
+}
+
+func TestDeletePriceSchedule(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.PriceSchedule{})
+	product, _ := entity.NewProduct("Shirt", price(1000))
+	assert.NoError(t, NewProduct(db).Create(product))
+	scheduleDB := NewPriceSchedule(db)
+
+	now := time.Now()
+	end := now.Add(time.Hour)
+	started, _ := entity.NewPriceSchedule(product.ID, price(800), now.Add(-time.Hour), &end)
+	upcoming, _ := entity.NewPriceSchedule(product.ID, price(900), end, nil)
+	assert.NoError(t, scheduleDB.Create(started))
+	assert.NoError(t, scheduleDB.Create(upcoming))
+
+	assert.ErrorIs(t, scheduleDB.Delete(product.ID.String(), started.ID.String(), now), ErrPriceScheduleStarted)
+	assert.NoError(t, scheduleDB.Delete(product.ID.String(), upcoming.ID.String(), now))
+	assert.ErrorIs(t, scheduleDB.Delete(product.ID.String(), upcoming.ID.String(), now), gorm.ErrRecordNotFound)
+
+	schedules, _ := scheduleDB.FindByProduct(product.ID.String())
+	assert.Len(t, schedules, 1)
+}
+
+func TestFindActivePriceSchedulesInAnotherZone(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.PriceSchedule{})
+	product, _ := entity.NewProduct("Shirt", price(1000))
+	assert.NoError(t, NewProduct(db).Create(product))
+	scheduleDB := NewPriceSchedule(db)
+
+	// A zone behind the local one makes the start sort before now as text.
+	now := time.Now()
+	_, offset := now.Zone()
+	behind := time.FixedZone("behind", offset-3*60*60)
+	start := now.Add(2 * time.Hour).In(behind)
+	sale, _ := entity.NewPriceSchedule(product.ID, price(800), start, nil)
+	assert.NoError(t, scheduleDB.Create(sale))
+
+	active, err := scheduleDB.FindActive([]string{product.ID.String()}, now)
+	assert.NoError(t, err)
+	assert.Empty(t, active)
+
+	active, err = scheduleDB.FindActive([]string{product.ID.String()}, start)
+	assert.NoError(t, err)
+	assert.Len(t, active, 1)
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ProductCursor points at the last product of a keyset page. Listings are
+// ordered by (created_at, id), so the pair identifies a stable position even
+// when rows are inserted between requests.
+type ProductCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Encode returns the opaque representation handed out to clients.
+func (c ProductCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeProductCursor(s string) (*ProductCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found {
+		return nil, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if _, err := entity.ParseID(id); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &ProductCursor{CreatedAt: t, ID: id}, nil
+}
@@ -1,68 +1,474 @@
 package database
 
 import (
+	"errors"
+	"time"
+
 	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/storage"
 	"gorm.io/gorm"
 )
 
+var ErrVersionConflict = errors.New("product was modified by another request")
+
 type Product struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	actor  string
+	tenant *string
+	blobs  storage.BlobStorage
 }
 
 func NewProduct(db *gorm.DB) *Product {
 	return &Product{DB: db}
 }
 
+// WithBlobStorage sets the storage the files of product images live in, so
+// purging a product removes them too. Without it only the image rows are
+// removed.
+func (p *Product) WithBlobStorage(blobs storage.BlobStorage) *Product {
+	p.blobs = blobs
+	return p
+}
+
+// WithActor returns a copy of the repository that attributes the changes it
+// makes to userID in the product history.
+func (p *Product) WithActor(userID string) ProductInterface {
+	return &Product{DB: p.DB, actor: userID, tenant: p.tenant, blobs: p.blobs}
+}
+
+// ForTenant returns a copy of the repository that only sees the products of
+// tenantID, and their history, and creates new products in it. Products of
+// other tenants cannot be read or changed through it, even by ID. The
+// repository returned by NewProduct sees every tenant, which only
+// background jobs should rely on.
+func (p *Product) ForTenant(tenantID string) ProductInterface {
+	return &Product{DB: p.DB, actor: p.actor, tenant: &tenantID, blobs: p.blobs}
+}
+
+// scope restricts a query on products or on their history to the tenant of
+// the repository, if it has one.
+func (p *Product) scope(db *gorm.DB) *gorm.DB {
+	if p.tenant == nil {
+		return db
+	}
+	return db.Where("tenant_id = ?", *p.tenant)
+}
+
+// Transaction runs fn with a copy of the repository bound to a database
+// transaction, which is committed when fn returns nil and rolled back
+// otherwise. Calls on the copy, including nested Transaction calls, run
+// inside it as savepoints, so they can fail without aborting the whole
+// transaction.
+func (p *Product) Transaction(fn func(tx ProductInterface) error) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(&Product{DB: tx, actor: p.actor, tenant: p.tenant, blobs: p.blobs})
+	})
+}
+
 func (p *Product) Create(product *entity.Product) error {
-	if err := p.DB.Create(product).Error; err != nil {
-		return err
+	if p.tenant != nil {
+		product.TenantID = *p.tenant
 	}
-	return nil
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkCategory(tx, product.TenantID, product.CategoryID); err != nil {
+			return err
+		}
+		if err := assignSlug(tx, product, ""); err != nil {
+			return err
+		}
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		return p.record(tx, entity.ProductCreated, nil, product)
+	})
 }
 
 func (p *Product) FindById(id string) (*entity.Product, error) {
-	var product entity.Product
-	if err := p.DB.First(&product, "id = ?", id).Error; err != nil {
-		return nil, err
-	}
-	return &product, nil
+	return findProduct(p.scope(p.DB), id)
 }
 
+// Update writes every field of product as long as the stored version still
+// matches product.Version, then bumps the version.
 func (p *Product) Update(product *entity.Product) error {
-	_, err := p.FindById(product.ID.String())
+	return p.update(product, []string{"*"})
+}
+
+// UpdateFields persists only the given fields of product, with the same
+// version check as Update.
+func (p *Product) UpdateFields(product *entity.Product, fields []string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return p.update(product, fields)
+}
+
+func (p *Product) update(product *entity.Product, fields []string) error {
+	columns, err := productColumns(p.DB, fields)
 	if err != nil {
 		return err
 	}
-	if err := p.DB.Save(product).Error; err != nil {
-		return err
+
+	expected := product.Version
+	product.Version++
+
+	err = p.DB.Transaction(func(tx *gorm.DB) error {
+		before, err := findProduct(p.scope(tx), product.ID.String())
+		if err != nil {
+			return err
+		}
+		if hasField(fields, "CategoryID") {
+			if err := checkCategory(tx, before.TenantID, product.CategoryID); err != nil {
+				return err
+			}
+		}
+		if hasField(fields, "Slug") || hasField(fields, "SKU") {
+			product.TenantID = before.TenantID
+			if err := assignSlug(tx, product, before.Slug); err != nil {
+				return err
+			}
+			// Products created before slugs existed get one on their
+			// first SKU change.
+			if !hasField(fields, "Slug") && product.Slug != before.Slug {
+				columns = append(columns, "slug")
+			}
+		}
+
+		result := p.scope(tx.Model(product)).
+			Where("version = ?", expected).
+			Select(append(columns, "version")).
+			Omit("ID", "OwnerID", "TenantID", "CreatedAt", "Tags", "ReviewCount", "RatingSum", "AverageRating").
+			Updates(product)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+		if hasField(fields, "Tags") {
+			if err := tx.Model(product).Association("Tags").Replace(product.Tags); err != nil {
+				return err
+			}
+		}
+
+		after, err := findProduct(tx, product.ID.String())
+		if err != nil {
+			return err
+		}
+		return p.record(tx, entity.ProductUpdated, before, after)
+	})
+	if err != nil {
+		product.Version = expected
 	}
-	return nil
+	return err
+}
+
+// Delete moves the product to the trash as long as the stored version still
+// matches.
+func (p *Product) Delete(id string, version int) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		before, err := findProduct(p.scope(tx), id)
+		if err != nil {
+			return err
+		}
+
+		result := p.scope(tx).Where("version = ?", version).Delete(&entity.Product{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+
+		after, err := findProduct(tx.Unscoped(), id)
+		if err != nil {
+			return err
+		}
+		return p.record(tx, entity.ProductDeleted, before, after)
+	})
 }
 
-func (p *Product) Delete(id string) error {
-	_, err := p.FindById(id)
+// FindHistory lists the recorded changes of a product, oldest first. Entries
+// outlive the product itself, so purged products still have a history.
+func (p *Product) FindHistory(id string) ([]*entity.ProductHistory, error) {
+	var history []*entity.ProductHistory
+	err := p.scope(p.DB).Where("product_id = ?", id).Order("created_at asc").Order("id asc").Find(&history).Error
+	return history, err
+}
+
+func (p *Product) record(tx *gorm.DB, action string, before, after *entity.Product) error {
+	productID := after
+	if productID == nil {
+		productID = before
+	}
+	history, err := entity.NewProductHistory(productID.ID, action, p.actor, before, after)
 	if err != nil {
 		return err
 	}
-	if err := p.DB.Delete(&entity.Product{}, "id = ?", id).Error; err != nil {
-		return err
+	return tx.Create(history).Error
+}
+
+// productColumns maps entity.Product field names to their columns. Embedded
+// fields such as Price expand to every column they are stored in.
+func productColumns(db *gorm.DB, fields []string) ([]string, error) {
+	if len(fields) == 1 && fields[0] == "*" {
+		return fields, nil
 	}
 
-	return nil
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&entity.Product{}); err != nil {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	for _, field := range fields {
+		wanted[field] = true
+	}
+	var columns []string
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName != "" && wanted[field.BindNames[0]] {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return columns, nil
 }
 
-func (p *Product) FindAll(page, limit int, sort string) ([]*entity.Product, error) {
-	var products []*entity.Product
-	var err error
-	if sort != "" && sort != "asc" && sort != "desc" {
-		sort = "asc"
+// hasField reports whether fields, as given to update, include field.
+func hasField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == "*" || f == field {
+			return true
+		}
 	}
+	return false
+}
 
+// preloadTags loads the tags of the products found by db, by name.
+func preloadTags(db *gorm.DB) *gorm.DB {
+	return db.Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name")
+	})
+}
+
+func findProduct(db *gorm.DB, id string) (*entity.Product, error) {
+	var product entity.Product
+	if err := preloadTags(db).First(&product, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (p *Product) FindAll(page, limit int, sort ProductSort, filter ProductFilter) ([]*entity.Product, error) {
+	var products []*entity.Product
+
+	query := sort.apply(filter.apply(preloadTags(p.scope(p.DB))))
 	if page != 0 && limit != 0 {
-		err = p.DB.Limit(limit).Offset((page - 1) * limit).Order("created_at " + sort).Find(&products).Error
-	} else {
-		err = p.DB.Order("created_at " + sort).Find(&products).Error
+		query = query.Limit(limit).Offset((page - 1) * limit)
+	}
+	err := query.Find(&products).Error
+
+	return products, err
+}
+
+func (p *Product) Count(filter ProductFilter) (int64, error) {
+	var total int64
+	err := filter.apply(p.scope(p.DB.Model(&entity.Product{}))).Count(&total).Error
+	return total, err
+}
+
+// FindAllAfter returns up to limit products following cursor in
+// (created_at, id) order. A nil cursor starts from the beginning. The
+// returned cursor is nil once there are no more products to read.
+func (p *Product) FindAllAfter(cursor *ProductCursor, limit int, sort ProductSort, filter ProductFilter) ([]*entity.Product, *ProductCursor, error) {
+	var products []*entity.Product
+	desc, err := sort.cursorDirection()
+	if err != nil {
+		return nil, nil, err
+	}
+	direction := "asc"
+	if desc {
+		direction = "desc"
+	}
+
+	query := filter.apply(preloadTags(p.scope(p.DB)))
+	if cursor != nil {
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		createdAt := cursor.CreatedAt.Local()
+		query = query.Where(
+			"created_at "+op+" ? OR (created_at = ? AND id "+op+" ?)",
+			createdAt, createdAt, cursor.ID,
+		)
 	}
 
+	err = query.Order("created_at " + direction).Order("id " + direction).Limit(limit + 1).Find(&products).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(products) <= limit {
+		return products, nil, nil
+	}
+	products = products[:limit]
+	last := products[limit-1]
+	return products, &ProductCursor{CreatedAt: last.CreatedAt, ID: last.ID.String()}, nil
+}
+
+// FindDeleted lists soft deleted products, most recently deleted first.
+func (p *Product) FindDeleted(page, limit int) ([]*entity.Product, error) {
+	var products []*entity.Product
+	query := preloadTags(p.scope(p.DB.Unscoped())).Where("deleted_at IS NOT NULL").Order("deleted_at desc").Order("id asc")
+	if page != 0 && limit != 0 {
+		query = query.Limit(limit).Offset((page - 1) * limit)
+	}
+	err := query.Find(&products).Error
 	return products, err
 }
+
+// FindDeletedById finds a product that is in the trash.
+func (p *Product) FindDeletedById(id string) (*entity.Product, error) {
+	return findDeletedProduct(p.scope(p.DB), id)
+}
+
+func (p *Product) CountDeleted() (int64, error) {
+	var total int64
+	err := p.scope(p.DB.Unscoped().Model(&entity.Product{})).Where("deleted_at IS NOT NULL").Count(&total).Error
+	return total, err
+}
+
+// Restore brings a soft deleted product back and bumps its version.
+func (p *Product) Restore(id string) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		before, err := findDeletedProduct(p.scope(tx), id)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().Model(&entity.Product{}).
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return err
+		}
+
+		after, err := findProduct(tx, id)
+		if err != nil {
+			return err
+		}
+		return p.record(tx, entity.ProductRestored, before, after)
+	})
+}
+
+// Purge permanently removes a product that is already in the trash.
+func (p *Product) Purge(id string) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		before, err := findDeletedProduct(p.scope(tx), id)
+		if err != nil {
+			return err
+		}
+		return p.purge(tx, before)
+	})
+}
+
+// PurgeDeletedBefore permanently removes products trashed before t and
+// returns how many were removed.
+func (p *Product) PurgeDeletedBefore(t time.Time) (int64, error) {
+	var products []*entity.Product
+	err := p.DB.Transaction(func(tx *gorm.DB) error {
+		err := preloadTags(p.scope(tx.Unscoped())).Where("deleted_at IS NOT NULL AND deleted_at < ?", t).Find(&products).Error
+		if err != nil {
+			return err
+		}
+		for _, product := range products {
+			if err := p.purge(tx, product); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(products)), nil
+}
+
+// BackfillPublishedAt sets the publication time of published products
+// without one, which were created before products had a status, to their
+// creation time and returns how many it changed.
+func (p *Product) BackfillPublishedAt() (int64, error) {
+	result := p.scope(p.DB.Unscoped().Model(&entity.Product{})).
+		Where("status = ? AND published_at IS NULL", entity.ProductPublished).
+		Update("published_at", gorm.Expr("created_at"))
+	return result.RowsAffected, result.Error
+}
+
+func (p *Product) purge(tx *gorm.DB, product *entity.Product) error {
+	if err := tx.Model(product).Association("Tags").Clear(); err != nil {
+		return err
+	}
+	if err := tx.Where("product_id = ?", product.ID).Delete(&entity.Variant{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("product_id = ?", product.ID).Delete(&entity.PriceSchedule{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("product_id = ?", product.ID).Delete(&entity.SlugRedirect{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("product_id = ?", product.ID).Delete(&entity.Review{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("product_id = ?", product.ID).Delete(&entity.StockLevel{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("product_id = ?", product.ID).Delete(&entity.StockMovement{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("product_id = ?", product.ID).Delete(&entity.Reservation{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("product_id = ?", product.ID).Delete(&entity.CartItem{}).Error; err != nil {
+		return err
+	}
+	var images []entity.ProductImage
+	if err := tx.Where("product_id = ?", product.ID).Find(&images).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("product_id = ?", product.ID).Delete(&entity.ProductImage{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Delete(&entity.Product{}, "id = ?", product.ID).Error; err != nil {
+		return err
+	}
+	if err := p.record(tx, entity.ProductPurged, product, nil); err != nil {
+		return err
+	}
+	return p.deleteImageFiles(images)
+}
+
+// deleteImageFiles removes the files of images from blob storage. It runs
+// last in a purge because files cannot be restored by a rollback, and a
+// failure still rolls the rows back so the purge can be retried.
+func (p *Product) deleteImageFiles(images []entity.ProductImage) error {
+	if p.blobs == nil {
+		return nil
+	}
+	for i := range images {
+		keys := []string{images[i].OriginalKey()}
+		for size := range entity.ThumbnailSizes {
+			key, _ := images[i].ThumbnailKey(size)
+			keys = append(keys, key)
+		}
+		for _, key := range keys {
+			if err := p.blobs.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func findDeletedProduct(db *gorm.DB, id string) (*entity.Product, error) {
+	return findProduct(db.Unscoped().Where("deleted_at IS NOT NULL"), id)
+}
@@ -3,21 +3,29 @@ package database
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/storage"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+func price(cents int64) entityPKG.Money {
+	return entityPKG.Money{Amount: cents, Currency: "BRL"}
+}
+
 func TestCreateNewProduct(t *testing.T) {
 	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
 	if err != nil {
 		t.Error(err)
 	}
-	db.AutoMigrate(&entity.Product{})
-	product, err := entity.NewProduct("product 1", 10.00)
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	product, err := entity.NewProduct("product 1", price(1000))
 	assert.NoError(t, err)
 
 	productDB := NewProduct(db)
@@ -31,29 +39,29 @@ func TestFindAllProducts(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	db.AutoMigrate(&entity.Product{})
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
 
 	for i := 1; i < 24; i++ {
-		product, err := entity.NewProduct(fmt.Sprintf("Product %d", i), rand.Float64()*100)
+		product, err := entity.NewProduct(fmt.Sprintf("Product %d", i), price(rand.Int63n(10000)+1))
 		assert.NoError(t, err)
 		db.Create(product)
 	}
 
 	productDb := NewProduct(db)
-	products, err := productDb.FindAll(1, 10, "asc")
+	products, err := productDb.FindAll(1, 10, nil, ProductFilter{})
 
 	assert.NoError(t, err)
 	assert.Len(t, products, 10)
 	assert.Equal(t, "Product 1", products[0].Name)
 	assert.Equal(t, "Product 10", products[9].Name)
 
-	products, err = productDb.FindAll(2, 10, "asc")
+	products, err = productDb.FindAll(2, 10, nil, ProductFilter{})
 	assert.NoError(t, err)
 	assert.Len(t, products, 10)
 	assert.Equal(t, "Product 11", products[0].Name)
 	assert.Equal(t, "Product 20", products[9].Name)
 
-	products, err = productDb.FindAll(3, 10, "asc")
+	products, err = productDb.FindAll(3, 10, nil, ProductFilter{})
 	assert.NoError(t, err)
 	assert.Len(t, products, 3)
 	assert.Equal(t, "Product 21", products[0].Name)
@@ -65,9 +73,9 @@ func TestFindProductById(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	db.AutoMigrate(&entity.Product{})
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
 
-	product, err := entity.NewProduct("Product 1", 10.00)
+	product, err := entity.NewProduct("Product 1", price(1000))
 	assert.NoError(t, err)
 	db.Create(product)
 
@@ -86,16 +94,843 @@ func TestDeleteProduct(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	db.AutoMigrate(&entity.Product{})
-	product, err := entity.NewProduct("Product 1", 10.00)
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	product, err := entity.NewProduct("Product 1", price(1000))
 	assert.NoError(t, err)
 	db.Create(product)
 
 	productDB := NewProduct(db)
-	err = productDB.Delete(product.ID.String())
+	err = productDB.Delete(product.ID.String(), product.Version)
 	assert.NoError(t, err)
 
 	product, err = productDB.FindById(product.ID.String())
 	assert.Error(t, err)
 	assert.Nil(t, product)
 }
+
+func TestFindAllProductsWithFilter(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+
+	names := []string{"Red Shirt", "Blue Shirt", "Red Hat", "100%_Cotton"}
+	for i, name := range names {
+		product, err := entity.NewProduct(name, price(int64(i+1)*1000))
+		assert.NoError(t, err)
+		product.CreatedAt = time.Date(2024, time.January, i+1, 0, 0, 0, 0, time.Local)
+		db.Create(product)
+	}
+
+	productDb := NewProduct(db)
+	products, err := productDb.FindAll(0, 0, nil, ProductFilter{Name: "shirt"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+
+	products, err = productDb.FindAll(0, 0, nil, ProductFilter{NamePrefix: "Red"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+	assert.Equal(t, "Red Shirt", products[0].Name)
+	assert.Equal(t, "Red Hat", products[1].Name)
+
+	products, err = productDb.FindAll(0, 0, nil, ProductFilter{Name: "%_"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, "100%_Cotton", products[0].Name)
+
+	minPrice, maxPrice := int64(1500), int64(3000)
+	products, err = productDb.FindAll(0, 0, nil, ProductFilter{MinPrice: &minPrice, MaxPrice: &maxPrice})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+	assert.Equal(t, "Blue Shirt", products[0].Name)
+	assert.Equal(t, "Red Hat", products[1].Name)
+
+	after := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2024, time.January, 4, 0, 0, 0, 0, time.UTC)
+	products, err = productDb.FindAll(0, 0, nil, ProductFilter{CreatedAfter: &after, CreatedBefore: &before})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, "Red Hat", products[0].Name)
+
+	total, err := productDb.Count(ProductFilter{Name: "shirt"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	total, err = productDb.Count(ProductFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+}
+
+func TestFindAllProductsAfterCursor(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+
+	createdAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.Local)
+	for i := 1; i <= 5; i++ {
+		product, err := entity.NewProduct(fmt.Sprintf("Product %d", i), price(1000))
+		assert.NoError(t, err)
+		product.CreatedAt = createdAt.Add(time.Duration(i) * time.Hour)
+		db.Create(product)
+	}
+
+	productDb := NewProduct(db)
+	products, cursor, err := productDb.FindAllAfter(nil, 2, nil, ProductFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+	assert.Equal(t, "Product 1", products[0].Name)
+	assert.NotNil(t, cursor)
+
+	// A product inserted before the cursor must not shift the next page.
+	early, err := entity.NewProduct("Early product", price(1000))
+	assert.NoError(t, err)
+	early.CreatedAt = createdAt
+	db.Create(early)
+
+	decoded, err := DecodeProductCursor(cursor.Encode())
+	assert.NoError(t, err)
+	products, cursor, err = productDb.FindAllAfter(decoded, 2, nil, ProductFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+	assert.Equal(t, "Product 3", products[0].Name)
+	assert.Equal(t, "Product 4", products[1].Name)
+
+	products, cursor, err = productDb.FindAllAfter(cursor, 2, nil, ProductFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, "Product 5", products[0].Name)
+	assert.Nil(t, cursor)
+}
+
+func TestDecodeInvalidProductCursor(t *testing.T) {
+	cursor, err := DecodeProductCursor("not-a-cursor")
+	assert.Nil(t, cursor)
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestFindAllProductsSortedByFields(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+
+	for _, p := range []struct {
+		name  string
+		price int64
+	}{{"B", 2000}, {"A", 2000}, {"C", 1000}} {
+		product, err := entity.NewProduct(p.name, price(p.price))
+		assert.NoError(t, err)
+		db.Create(product)
+	}
+	dollars, _ := entity.NewProduct("D", entityPKG.Money{Amount: 500, Currency: "USD"})
+	db.Create(dollars)
+
+	sort, err := ParseProductSort("price,-name")
+	assert.NoError(t, err)
+	assert.Equal(t, ProductSort{{Column: "price_amount"}, {Column: "name", Desc: true}}, sort)
+
+	productDb := NewProduct(db)
+	products, err := productDb.FindAll(0, 0, sort, ProductFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, products, 4)
+	assert.Equal(t, "C", products[0].Name)
+	assert.Equal(t, "B", products[1].Name)
+	assert.Equal(t, "A", products[2].Name)
+	assert.Equal(t, "D", products[3].Name)
+
+	_, _, err = productDb.FindAllAfter(nil, 10, sort, ProductFilter{})
+	assert.ErrorIs(t, err, ErrUnsupportedSortField)
+}
+
+func TestParseProductSortWithInvalidField(t *testing.T) {
+	_, err := ParseProductSort("price,password")
+	assert.ErrorIs(t, err, ErrInvalidSortField)
+
+	_, err = ParseProductSort("price,-price")
+	assert.ErrorIs(t, err, ErrInvalidSortField)
+
+	sort, err := ParseProductSort("desc")
+	assert.NoError(t, err)
+	assert.True(t, sort.SupportsCursor())
+}
+
+func TestUpdateProductFields(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	product, err := entity.NewProduct("Product 1", price(1000))
+	assert.NoError(t, err)
+	db.Create(product)
+
+	productDB := NewProduct(db)
+	partial := &entity.Product{ID: product.ID, Name: "Product 2", Version: product.Version}
+	err = productDB.UpdateFields(partial, []string{"Name"})
+	assert.NoError(t, err)
+
+	found, err := productDB.FindById(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Product 2", found.Name)
+	assert.Equal(t, price(1000), found.Price)
+	assert.True(t, product.CreatedAt.Equal(found.CreatedAt))
+
+	found.Name = "Ignored"
+	found.Price = entityPKG.Money{Amount: 500, Currency: "USD"}
+	err = productDB.UpdateFields(found, []string{"Price"})
+	assert.NoError(t, err)
+
+	found, err = productDB.FindById(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Product 2", found.Name)
+	assert.Equal(t, entityPKG.Money{Amount: 500, Currency: "USD"}, found.Price)
+}
+
+func TestUpdateProductWithStaleVersion(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	product, err := entity.NewProduct("Product 1", price(1000))
+	assert.NoError(t, err)
+	db.Create(product)
+
+	productDB := NewProduct(db)
+	first, second := *product, *product
+
+	first.Name = "First editor"
+	err = productDB.Update(&first)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, first.Version)
+
+	second.Name = "Second editor"
+	err = productDB.Update(&second)
+	assert.Equal(t, ErrVersionConflict, err)
+	assert.Equal(t, 1, second.Version)
+
+	err = productDB.Delete(product.ID.String(), 1)
+	assert.Equal(t, ErrVersionConflict, err)
+
+	found, err := productDB.FindById(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "First editor", found.Name)
+	assert.Equal(t, 2, found.Version)
+	assert.True(t, product.CreatedAt.Equal(found.CreatedAt))
+
+	missing, _ := entity.NewProduct("Missing", price(1000))
+	err = productDB.Update(missing)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestSoftDeleteAndRestoreProduct(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	product, err := entity.NewProduct("Product 1", price(1000))
+	assert.NoError(t, err)
+	db.Create(product)
+
+	productDB := NewProduct(db)
+	err = productDB.Delete(product.ID.String(), product.Version)
+	assert.NoError(t, err)
+
+	products, err := productDB.FindAll(0, 0, nil, ProductFilter{})
+	assert.NoError(t, err)
+	assert.Empty(t, products)
+
+	trash, err := productDB.FindDeleted(1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+	assert.True(t, trash[0].DeletedAt.Valid)
+
+	total, err := productDB.CountDeleted()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	deleted, err := productDB.FindDeletedById(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, product.ID, deleted.ID)
+
+	err = productDB.Restore(product.ID.String())
+	assert.NoError(t, err)
+
+	found, err := productDB.FindById(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, found.Version)
+
+	err = productDB.Restore(product.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	_, err = productDB.FindDeletedById(product.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestPurgeProduct(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.Variant{}, &entity.PriceSchedule{}, &entity.ProductImage{}, &entity.Review{},
+		&entity.StockLevel{}, &entity.StockMovement{}, &entity.Reservation{}, &entity.CartItem{})
+	productDB := NewProduct(db)
+
+	old, _ := entity.NewProduct("Old", price(1000))
+	recent, _ := entity.NewProduct("Recent", price(1000))
+	live, _ := entity.NewProduct("Live", price(1000))
+	for _, p := range []*entity.Product{old, recent, live} {
+		db.Create(p)
+	}
+
+	err = productDB.Purge(live.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	assert.NoError(t, productDB.Delete(old.ID.String(), old.Version))
+	assert.NoError(t, productDB.Delete(recent.ID.String(), recent.Version))
+	db.Unscoped().Model(old).Update("deleted_at", time.Now().AddDate(0, 0, -40))
+
+	purged, err := productDB.PurgeDeletedBefore(time.Now().AddDate(0, 0, -30))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	review, _ := entity.NewReview(recent.ID, "user-1", 5, "Great", "Great product")
+	db.Create(review)
+	movement, _ := entity.NewStockMovement(recent.ID, entity.StockReceived, 5, "restock", "user-1")
+	reservation, _ := entity.NewReservation(recent.ID, 1, "user-1", time.Hour)
+	db.Create(&entity.StockLevel{ProductID: recent.ID, OnHand: 5, Reserved: 1})
+	db.Create(movement)
+	db.Create(reservation)
+	item, _ := entity.NewCartItem("user-1", recent.ID, price(1000), 1)
+	db.Create(item)
+
+	err = productDB.Purge(recent.ID.String())
+	assert.NoError(t, err)
+
+	var count int64
+	db.Unscoped().Model(&entity.Product{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	db.Model(&entity.Review{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	for _, model := range []interface{}{&entity.StockLevel{}, &entity.StockMovement{}, &entity.Reservation{}, &entity.CartItem{}} {
+		db.Model(model).Count(&count)
+		assert.Equal(t, int64(0), count)
+	}
+}
+
+func TestPurgeProductRemovesImages(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.Variant{}, &entity.PriceSchedule{}, &entity.ProductImage{}, &entity.Review{},
+		&entity.StockLevel{}, &entity.StockMovement{}, &entity.Reservation{}, &entity.CartItem{})
+	blobs, err := storage.NewLocal(t.TempDir())
+	assert.NoError(t, err)
+	productDB := NewProduct(db).WithBlobStorage(blobs)
+
+	product, _ := entity.NewProduct("Shirt", price(1000))
+	db.Create(product)
+	image, _ := entity.NewProductImage(product.ID, "image/png", 10, 100, 100)
+	db.Create(image)
+	keys := []string{image.OriginalKey()}
+	for size := range entity.ThumbnailSizes {
+		key, _ := image.ThumbnailKey(size)
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		assert.NoError(t, blobs.Put(key, strings.NewReader("image")))
+	}
+
+	assert.NoError(t, productDB.Delete(product.ID.String(), product.Version))
+	assert.NoError(t, productDB.Purge(product.ID.String()))
+
+	var count int64
+	db.Model(&entity.ProductImage{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+	for _, key := range keys {
+		_, err := blobs.Get(key)
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound, key)
+	}
+}
+
+func TestProductHistory(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	product, err := entity.NewProduct("Product 1", price(1000))
+	assert.NoError(t, err)
+
+	productDB := NewProduct(db).WithActor("user-1")
+	assert.NoError(t, productDB.Create(product))
+
+	product.Price = price(1250)
+	assert.NoError(t, productDB.Update(product))
+	assert.NoError(t, productDB.Delete(product.ID.String(), product.Version))
+	assert.NoError(t, productDB.Restore(product.ID.String()))
+
+	history, err := productDB.FindHistory(product.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, history, 4)
+
+	assert.Equal(t, entity.ProductCreated, history[0].Action)
+	assert.Equal(t, "user-1", history[0].UserID)
+	assert.Equal(t, "Product 1", history[0].Changes["name"].After)
+
+	assert.Equal(t, entity.ProductUpdated, history[1].Action)
+	assert.Equal(t, entity.FieldChange{
+		Before: map[string]interface{}{"amount": "10.00", "currency": "BRL"},
+		After:  map[string]interface{}{"amount": "12.50", "currency": "BRL"},
+	}, history[1].Changes["price"])
+	assert.Equal(t, entity.FieldChange{Before: 1.0, After: 2.0}, history[1].Changes["version"])
+	assert.NotContains(t, history[1].Changes, "name")
+
+	assert.Equal(t, entity.ProductDeleted, history[2].Action)
+	assert.Nil(t, history[2].Changes["deleted_at"].Before)
+	assert.NotNil(t, history[2].Changes["deleted_at"].After)
+
+	assert.Equal(t, entity.ProductRestored, history[3].Action)
+}
+
+func TestFindAllProductsByCategoryAndTag(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Category{}, &entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	categoryDB := NewCategory(db)
+	productDB := NewProduct(db)
+
+	electronics, _ := entity.NewCategory("Electronics", nil)
+	phones, _ := entity.NewCategory("Phones", &electronics.ID)
+	books, _ := entity.NewCategory("Books", nil)
+	for _, category := range []*entity.Category{electronics, phones, books} {
+		assert.NoError(t, categoryDB.Create(category))
+	}
+
+	create := func(name string, category *entity.Category, tags ...string) {
+		product, err := entity.NewProduct(name, price(1000))
+		assert.NoError(t, err)
+		product.CategoryID = &category.ID
+		product.SetTags(tags)
+		assert.NoError(t, productDB.Create(product))
+	}
+	create("TV", electronics, "sale")
+	create("Phone", phones, "sale", "new")
+	create("Novel", books, "new")
+
+	names := func(filter ProductFilter) []string {
+		products, err := productDB.FindAll(0, 0, nil, filter)
+		assert.NoError(t, err)
+		var names []string
+		for _, product := range products {
+			names = append(names, product.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"TV", "Phone"}, names(ProductFilter{CategoryID: electronics.ID.String()}))
+	assert.Equal(t, []string{"Phone"}, names(ProductFilter{CategoryID: phones.ID.String()}))
+	assert.Equal(t, []string{"Phone", "Novel"}, names(ProductFilter{Tags: []string{"new"}}))
+	assert.Equal(t, []string{"Phone"}, names(ProductFilter{Tags: []string{"new", "sale"}}))
+	assert.Equal(t, []string{"TV", "Phone"}, names(ProductFilter{CategoryID: electronics.ID.String(), Tags: []string{"sale"}}))
+
+	total, err := productDB.Count(ProductFilter{Tags: []string{"sale"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+}
+
+func TestUpdateProductTags(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Category{}, &entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	productDB := NewProduct(db)
+
+	product, err := entity.NewProduct("Product 1", price(1000))
+	assert.NoError(t, err)
+	product.SetTags([]string{"sale", "new"})
+	assert.NoError(t, productDB.Create(product))
+
+	found, err := productDB.FindById(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.Tag{{Name: "new"}, {Name: "sale"}}, found.Tags)
+
+	found.SetTags([]string{"clearance", "sale"})
+	assert.NoError(t, productDB.UpdateFields(found, []string{"Tags"}))
+
+	found, err = productDB.FindById(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.Tag{{Name: "clearance"}, {Name: "sale"}}, found.Tags)
+	assert.Equal(t, 2, found.Version)
+
+	history, err := productDB.FindHistory(product.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, []interface{}{"new", "sale"}, history[1].Changes["tags"].Before)
+	assert.Equal(t, []interface{}{"clearance", "sale"}, history[1].Changes["tags"].After)
+
+	missing, _ := entity.NewCategory("Missing", nil)
+	found.CategoryID = &missing.ID
+	assert.ErrorIs(t, productDB.UpdateFields(found, []string{"CategoryID"}), ErrCategoryNotFound)
+}
+
+func TestProductTransaction(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	productDB := NewProduct(db).WithActor("user")
+
+	committed, _ := entity.NewProduct("committed", price(1000))
+	err = productDB.Transaction(func(tx ProductInterface) error {
+		return tx.Create(committed)
+	})
+	assert.NoError(t, err)
+
+	rolledBack, _ := entity.NewProduct("rolled back", price(1000))
+	err = productDB.Transaction(func(tx ProductInterface) error {
+		if err := tx.Create(rolledBack); err != nil {
+			return err
+		}
+		return tx.Delete(committed.ID.String(), committed.Version+1)
+	})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	_, err = productDB.FindById(rolledBack.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = productDB.FindById(committed.ID.String())
+	assert.NoError(t, err)
+
+	history, _ := productDB.FindHistory(committed.ID.String())
+	assert.Len(t, history, 1)
+	assert.Equal(t, "user", history[0].UserID)
+}
+
+func TestNestedProductTransaction(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	productDB := NewProduct(db)
+
+	kept, _ := entity.NewProduct("kept", price(1000))
+	discarded, _ := entity.NewProduct("discarded", price(1000))
+	err = productDB.Transaction(func(tx ProductInterface) error {
+		if err := tx.Create(kept); err != nil {
+			return err
+		}
+		err := tx.Transaction(func(nested ProductInterface) error {
+			if err := nested.Create(discarded); err != nil {
+				return err
+			}
+			return ErrVersionConflict
+		})
+		assert.ErrorIs(t, err, ErrVersionConflict)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	_, err = productDB.FindById(kept.ID.String())
+	assert.NoError(t, err)
+	_, err = productDB.FindById(discarded.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestFindAllProductsByOwner(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	productDB := NewProduct(db)
+	for i, owner := range []string{"alice", "bob", "alice", ""} {
+		product, err := entity.NewProduct(fmt.Sprintf("Product %d", i+1), price(1000))
+		assert.NoError(t, err)
+		product.OwnerID = owner
+		assert.NoError(t, productDB.Create(product))
+	}
+
+	products, err := productDB.FindAll(0, 0, nil, ProductFilter{OwnerID: "alice"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+	for _, product := range products {
+		assert.Equal(t, "alice", product.OwnerID)
+	}
+	total, err := productDB.Count(ProductFilter{OwnerID: "bob"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// Updates never hand the product over to someone else.
+	products[0].Name = "Renamed"
+	products[0].OwnerID = "bob"
+	assert.NoError(t, productDB.Update(products[0]))
+	found, err := productDB.FindById(products[0].ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", found.Name)
+	assert.Equal(t, "alice", found.OwnerID)
+}
+
+func TestProductTenantIsolation(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.Tag{}, &entity.Variant{}, &entity.PriceSchedule{}, &entity.ProductImage{}, &entity.Review{},
+		&entity.StockLevel{}, &entity.StockMovement{}, &entity.Reservation{}, &entity.CartItem{})
+	tenantA := NewProduct(db).ForTenant("tenant-a")
+	tenantB := NewProduct(db).ForTenant("tenant-b")
+
+	productA, _ := entity.NewProduct("Product A", price(1000))
+	assert.NoError(t, tenantA.Create(productA))
+	assert.Equal(t, "tenant-a", productA.TenantID)
+	productB, _ := entity.NewProduct("Product B", price(2000))
+	productB.TenantID = "tenant-a"
+	assert.NoError(t, tenantB.Create(productB))
+	assert.Equal(t, "tenant-b", productB.TenantID)
+	idA := productA.ID.String()
+
+	products, err := tenantB.FindAll(0, 0, nil, ProductFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, productB.ID, products[0].ID)
+	products, _, err = tenantB.FindAllAfter(nil, 10, nil, ProductFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	total, err := tenantB.Count(ProductFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// Knowing the ID of a product of another tenant gives no access to it.
+	_, err = tenantB.FindById(idA)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	history, err := tenantB.FindHistory(idA)
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+	stolen := *productA
+	stolen.Name = "Stolen"
+	assert.ErrorIs(t, tenantB.Update(&stolen), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, tenantB.UpdateFields(&stolen, []string{"Name"}), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, tenantB.Delete(idA, productA.Version), gorm.ErrRecordNotFound)
+
+	found, err := tenantA.FindById(idA)
+	assert.NoError(t, err)
+	assert.Equal(t, "Product A", found.Name)
+
+	assert.NoError(t, tenantA.Delete(idA, productA.Version))
+	trash, err := tenantB.FindDeleted(0, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+	total, err = tenantB.CountDeleted()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	_, err = tenantB.FindDeletedById(idA)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, tenantB.Restore(idA), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, tenantB.Purge(idA), gorm.ErrRecordNotFound)
+	purged, err := tenantB.PurgeDeletedBefore(time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+
+	// Transactions and actors keep the tenant of the repository.
+	err = tenantB.WithActor("user-b").Transaction(func(tx ProductInterface) error {
+		_, err := tx.FindById(idA)
+		return err
+	})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	history, err = tenantA.FindHistory(idA)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	// Repositories without a tenant, as used by background jobs, see all.
+	total, err = NewProduct(db).CountDeleted()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.NoError(t, tenantA.Purge(idA))
+	history, err = tenantA.FindHistory(idA)
+	assert.NoError(t, err)
+	assert.Len(t, history, 3)
+}
+
+func TestProductSlugs(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	productDB := NewProduct(db).ForTenant("tenant-1")
+
+	first, _ := entity.NewProduct("Blue Shirt", price(1000))
+	second, _ := entity.NewProduct("Blue shirt", price(1000))
+	assert.NoError(t, productDB.Create(first))
+	assert.NoError(t, productDB.Create(second))
+	assert.Equal(t, "blue-shirt", first.Slug)
+	assert.Equal(t, "blue-shirt-2", second.Slug)
+
+	// Numbered slugs of long names stay within the slug length.
+	longName := strings.Repeat("a", 80)
+	long, _ := entity.NewProduct(longName, price(1000))
+	longer, _ := entity.NewProduct(longName, price(1000))
+	assert.NoError(t, productDB.Create(long))
+	assert.NoError(t, productDB.Create(longer))
+	assert.Equal(t, longName, long.Slug)
+	assert.Equal(t, strings.Repeat("a", 78)+"-2", longer.Slug)
+	assert.True(t, entity.IsSlug(longer.Slug))
+
+	other, _ := entity.NewProduct("Blue Shirt", price(1000))
+	assert.NoError(t, NewProduct(db).ForTenant("tenant-2").Create(other))
+	assert.Equal(t, "blue-shirt", other.Slug)
+
+	taken, _ := entity.NewProduct("Shirt", price(1000))
+	taken.Slug = "blue-shirt"
+	assert.ErrorIs(t, productDB.Create(taken), ErrDuplicateSlug)
+
+	first.Slug = "navy-shirt"
+	assert.NoError(t, productDB.Update(first))
+	found, err := productDB.FindBySlug("navy-shirt")
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, found.ID)
+	_, err = productDB.FindBySlug("blue-shirt")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	found, err = productDB.FindSlugRedirect("blue-shirt")
+	assert.NoError(t, err)
+	assert.Equal(t, "navy-shirt", found.Slug)
+
+	// New products do not take slugs that still redirect.
+	third, _ := entity.NewProduct("Blue Shirt", price(1000))
+	assert.NoError(t, productDB.Create(third))
+	assert.Equal(t, "blue-shirt-3", third.Slug)
+
+	// Taking a redirected slug explicitly drops the redirect.
+	second.Slug = "blue-shirt"
+	assert.NoError(t, productDB.Update(second))
+	_, err = productDB.FindSlugRedirect("blue-shirt")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	found, _ = productDB.FindSlugRedirect("blue-shirt-2")
+	assert.Equal(t, second.ID, found.ID)
+}
+
+func TestProductSKUs(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	productDB := NewProduct(db).ForTenant("tenant-1")
+
+	sku := "SH-001"
+	first, _ := entity.NewProduct("Shirt", price(1000))
+	first.SetSKU(&sku)
+	assert.NoError(t, productDB.Create(first))
+	second, _ := entity.NewProduct("Shirt", price(1000))
+	assert.NoError(t, productDB.Create(second))
+	third, _ := entity.NewProduct("Shirt", price(1000))
+	assert.NoError(t, productDB.Create(third))
+
+	found, err := productDB.FindBySKU("SH-001")
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, found.ID)
+
+	second.SetSKU(&sku)
+	assert.ErrorIs(t, productDB.UpdateFields(second, []string{"SKU"}), ErrDuplicateSKU)
+
+	other, _ := entity.NewProduct("Shirt", price(1000))
+	other.SetSKU(&sku)
+	assert.NoError(t, NewProduct(db).ForTenant("tenant-2").Create(other))
+}
+
+func TestGenerateMissingSlugs(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	for i := 0; i < 2; i++ {
+		product, _ := entity.NewProduct("Old Product", price(1000))
+		db.Create(product)
+	}
+
+	productDB := NewProduct(db)
+	generated, err := productDB.GenerateMissingSlugs()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), generated)
+	_, err = productDB.FindBySlug("old-product")
+	assert.NoError(t, err)
+	_, err = productDB.FindBySlug("old-product-2")
+	assert.NoError(t, err)
+
+	generated, _ = productDB.GenerateMissingSlugs()
+	assert.Equal(t, int64(0), generated)
+}
+
+func TestProductStatus(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	productDB := NewProduct(db)
+
+	draft, _ := entity.NewProduct("Draft", price(1000))
+	assert.NoError(t, productDB.Create(draft))
+	published, _ := entity.NewProduct("Published", price(1000))
+	assert.NoError(t, productDB.Create(published))
+	assert.NoError(t, published.Transition(entity.ProductPublished, time.Now()))
+	assert.NoError(t, productDB.UpdateFields(published, []string{"Status", "PublishedAt"}))
+
+	found, err := productDB.FindById(published.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.ProductPublished, found.Status)
+	assert.NotNil(t, found.PublishedAt)
+	assert.Equal(t, 2, found.Version)
+
+	products, err := productDB.FindAll(0, 0, nil, ProductFilter{Status: entity.ProductPublished})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, published.ID, products[0].ID)
+	total, err := productDB.Count(ProductFilter{Status: entity.ProductDraft})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	history, err := productDB.FindHistory(published.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.ProductUpdated, history[len(history)-1].Action)
+}
+
+func TestBackfillPublishedAt(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{})
+	legacy, _ := entity.NewProduct("Legacy", price(1000))
+	legacy.Status = ""
+	db.Create(legacy)
+	draft, _ := entity.NewProduct("Draft", price(1000))
+	db.Create(draft)
+
+	productDB := NewProduct(db)
+	backfilled, err := productDB.BackfillPublishedAt()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), backfilled)
+
+	found, _ := productDB.FindById(legacy.ID.String())
+	assert.Equal(t, entity.ProductPublished, found.Status)
+	assert.True(t, found.PublishedAt.Equal(found.CreatedAt))
+	found, _ = productDB.FindById(draft.ID.String())
+	assert.Nil(t, found.PublishedAt)
+
+	backfilled, _ = productDB.BackfillPublishedAt()
+	assert.Equal(t, int64(0), backfilled)
+}
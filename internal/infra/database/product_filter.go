@@ -0,0 +1,77 @@
+package database
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductFilter narrows down the products returned by a listing. Zero values
+// (empty strings and nil pointers) are ignored. Price bounds are in minor
+// units of the stored price currency, so they are only meaningful along with
+// Currency. CategoryID also matches products in its subcategories and a
+// product must carry every one of Tags. MinRating leaves out products
+// without approved reviews.
+type ProductFilter struct {
+	Name          string
+	NamePrefix    string
+	Currency      string
+	MinPrice      *int64
+	MaxPrice      *int64
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	CategoryID    string
+	Tags          []string
+	OwnerID       string
+	MinRating     *float64
+	Status        string
+}
+
+func (f ProductFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.Name != "" {
+		db = db.Where("name LIKE ? ESCAPE '\\'", "%"+escapeLike(f.Name)+"%")
+	}
+	if f.NamePrefix != "" {
+		db = db.Where("name LIKE ? ESCAPE '\\'", escapeLike(f.NamePrefix)+"%")
+	}
+	if f.Currency != "" {
+		db = db.Where("price_currency = ?", f.Currency)
+	}
+	if f.MinPrice != nil {
+		db = db.Where("price_amount >= ?", *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		db = db.Where("price_amount <= ?", *f.MaxPrice)
+	}
+	// created_at is compared as text by sqlite, so bounds must be in the
+	// same zone the timestamps were written in.
+	if f.CreatedAfter != nil {
+		db = db.Where("created_at > ?", f.CreatedAfter.Local())
+	}
+	if f.CreatedBefore != nil {
+		db = db.Where("created_at < ?", f.CreatedBefore.Local())
+	}
+	if f.CategoryID != "" {
+		db = db.Where("category_id IN ("+categorySubtree+")", f.CategoryID)
+	}
+	if f.OwnerID != "" {
+		db = db.Where("owner_id = ?", f.OwnerID)
+	}
+	if f.Status != "" {
+		db = db.Where("status = ?", f.Status)
+	}
+	if f.MinRating != nil {
+		db = db.Where("review_count > 0 AND average_rating >= ?", *f.MinRating)
+	}
+	for _, tag := range f.Tags {
+		db = db.Where("id IN (SELECT product_id FROM product_tags WHERE tag_name = ?)", tag)
+	}
+	return db
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidImageOrder = errors.New("order must list every image of the product exactly once")
+
+type ProductImage struct {
+	DB *gorm.DB
+}
+
+func NewProductImage(db *gorm.DB) *ProductImage {
+	return &ProductImage{DB: db}
+}
+
+// Create appends image to the images of its product. The first image of a
+// product becomes its primary image.
+func (p *ProductImage) Create(image *entity.ProductImage) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := findProduct(tx, image.ProductID.String()); err != nil {
+			return err
+		}
+
+		var last struct {
+			Count    int64
+			Position int
+		}
+		err := tx.Model(&entity.ProductImage{}).
+			Select("COUNT(*) AS count, COALESCE(MAX(position), 0) AS position").
+			Where("product_id = ?", image.ProductID).
+			Scan(&last).Error
+		if err != nil {
+			return err
+		}
+		image.Position = last.Position + 1
+		image.Primary = last.Count == 0
+		return tx.Create(image).Error
+	})
+}
+
+// FindByProduct lists the images of a product in display order.
+func (p *ProductImage) FindByProduct(productID string) ([]*entity.ProductImage, error) {
+	var images []*entity.ProductImage
+	err := p.DB.Where("product_id = ?", productID).Order("position asc").Order("id asc").Find(&images).Error
+	return images, err
+}
+
+func (p *ProductImage) FindById(productID, id string) (*entity.ProductImage, error) {
+	var image entity.ProductImage
+	if err := p.DB.First(&image, "id = ? AND product_id = ?", id, productID).Error; err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// SetPrimary makes the image the only primary image of its product.
+func (p *ProductImage) SetPrimary(productID, id string) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		var image entity.ProductImage
+		if err := tx.First(&image, "id = ? AND product_id = ?", id, productID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entity.ProductImage{}).
+			Where("product_id = ?", productID).
+			Update("is_primary", gorm.Expr("id = ?", id)).Error
+	})
+}
+
+// Reorder sets the display order of the images of a product to ids.
+func (p *ProductImage) Reorder(productID string, ids []string) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		var images []*entity.ProductImage
+		if err := tx.Where("product_id = ?", productID).Find(&images).Error; err != nil {
+			return err
+		}
+		if len(images) != len(ids) {
+			return ErrInvalidImageOrder
+		}
+		existing := map[string]bool{}
+		for _, image := range images {
+			existing[image.ID.String()] = true
+		}
+		for position, id := range ids {
+			if !existing[id] {
+				return ErrInvalidImageOrder
+			}
+			delete(existing, id)
+			err := tx.Model(&entity.ProductImage{}).Where("id = ?", id).Update("position", position+1).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes an image. When it was the primary image, the next image in
+// display order takes its place.
+func (p *ProductImage) Delete(productID, id string) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		var image entity.ProductImage
+		if err := tx.First(&image, "id = ? AND product_id = ?", id, productID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&image).Error; err != nil {
+			return err
+		}
+		if !image.Primary {
+			return nil
+		}
+
+		var next entity.ProductImage
+		err := tx.Where("product_id = ?", productID).Order("position asc").Order("id asc").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_primary", true).Error
+	})
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestProductImages(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.ProductImage{})
+	product, _ := entity.NewProduct("Product 1", price(1000))
+	assert.NoError(t, NewProduct(db).Create(product))
+	imageDB := NewProductImage(db)
+
+	var images []*entity.ProductImage
+	for i := 0; i < 3; i++ {
+		image, err := entity.NewProductImage(product.ID, "image/png", 100, 10, 10)
+		assert.NoError(t, err)
+		assert.NoError(t, imageDB.Create(image))
+		images = append(images, image)
+	}
+	assert.True(t, images[0].Primary)
+	assert.False(t, images[1].Primary)
+	assert.Equal(t, 3, images[2].Position)
+
+	assert.NoError(t, imageDB.SetPrimary(product.ID.String(), images[1].ID.String()))
+	assert.NoError(t, imageDB.Reorder(product.ID.String(), []string{images[2].ID.String(), images[0].ID.String(), images[1].ID.String()}))
+	assert.ErrorIs(t, imageDB.Reorder(product.ID.String(), []string{images[2].ID.String(), images[2].ID.String(), images[1].ID.String()}), ErrInvalidImageOrder)
+
+	found, err := imageDB.FindByProduct(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, images[2].ID, found[0].ID)
+	assert.Equal(t, images[1].ID, found[2].ID)
+	assert.False(t, found[1].Primary)
+	assert.True(t, found[2].Primary)
+
+	assert.NoError(t, imageDB.Delete(product.ID.String(), images[1].ID.String()))
+	found, err = imageDB.FindByProduct(product.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+	assert.True(t, found[0].Primary)
+
+	assert.ErrorIs(t, imageDB.Delete(product.ID.String(), images[1].ID.String()), gorm.ErrRecordNotFound)
+}
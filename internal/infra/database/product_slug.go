@@ -0,0 +1,149 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+var ErrDuplicateSlug = errors.New("slug is already in use")
+
+// FindBySlug returns the product whose current slug is slug.
+func (p *Product) FindBySlug(slug string) (*entity.Product, error) {
+	return p.findBy("slug", slug)
+}
+
+func (p *Product) FindBySKU(sku string) (*entity.Product, error) {
+	return p.findBy("sku", sku)
+}
+
+func (p *Product) findBy(column, value string) (*entity.Product, error) {
+	var product entity.Product
+	if err := preloadTags(p.scope(p.DB)).First(&product, column+" = ?", value).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// FindSlugRedirect returns the product that used to have slug.
+func (p *Product) FindSlugRedirect(slug string) (*entity.Product, error) {
+	var redirect entity.SlugRedirect
+	if err := p.scope(p.DB).First(&redirect, "slug = ?", slug).Error; err != nil {
+		return nil, err
+	}
+	return findProduct(p.scope(p.DB), redirect.ProductID.String())
+}
+
+// GenerateMissingSlugs gives a slug to the products, in the trash or not,
+// created before products had one, and returns how many it changed. It
+// neither bumps their version nor records history.
+func (p *Product) GenerateMissingSlugs() (int64, error) {
+	var products []*entity.Product
+	err := p.DB.Transaction(func(tx *gorm.DB) error {
+		err := p.scope(tx.Unscoped()).Where("slug IS NULL OR slug = ''").Find(&products).Error
+		if err != nil {
+			return err
+		}
+		for _, product := range products {
+			if err := assignSlug(tx, product, ""); err != nil {
+				return err
+			}
+			err := tx.Unscoped().Model(&entity.Product{}).Where("id = ?", product.ID).Update("slug", product.Slug).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(products)), nil
+}
+
+// assignSlug checks that the slug and SKU of product are free in its tenant,
+// generating a slug from the name when it has none. previous is the slug the
+// product had until now, if any, which is kept as a redirect when it
+// changes.
+//
+// Slugs of products in the trash stay taken so restoring them cannot clash.
+// Slugs kept as redirects are avoided when generating one but can be taken
+// explicitly, which drops the redirect.
+func assignSlug(tx *gorm.DB, product *entity.Product, previous string) error {
+	if err := checkSKU(tx, product); err != nil {
+		return err
+	}
+
+	if product.Slug == "" {
+		base := entity.Slugify(product.Name)
+		product.Slug = base
+		for n := 2; ; n++ {
+			taken, err := slugTaken(tx, product, true)
+			if err != nil {
+				return err
+			}
+			if !taken {
+				break
+			}
+			product.Slug = entity.NumberedSlug(base, n)
+		}
+	} else {
+		taken, err := slugTaken(tx, product, false)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrDuplicateSlug
+		}
+		err = tx.Where("tenant_id = ? AND slug = ?", product.TenantID, product.Slug).Delete(&entity.SlugRedirect{}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if previous == "" || previous == product.Slug {
+		return nil
+	}
+	return tx.Create(&entity.SlugRedirect{
+		TenantID:  product.TenantID,
+		Slug:      previous,
+		ProductID: product.ID,
+		CreatedAt: time.Now(),
+	}).Error
+}
+
+// slugTaken reports whether another product of the tenant of product has
+// its slug and, when redirects is set, whether a redirect of another
+// product uses it.
+func slugTaken(tx *gorm.DB, product *entity.Product, redirects bool) (bool, error) {
+	var count int64
+	err := tx.Unscoped().Model(&entity.Product{}).
+		Where("tenant_id = ? AND slug = ? AND id <> ?", product.TenantID, product.Slug, product.ID).
+		Count(&count).Error
+	if err != nil || count > 0 || !redirects {
+		return count > 0, err
+	}
+	err = tx.Model(&entity.SlugRedirect{}).
+		Where("tenant_id = ? AND slug = ? AND product_id <> ?", product.TenantID, product.Slug, product.ID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func checkSKU(tx *gorm.DB, product *entity.Product) error {
+	if product.SKU == nil {
+		return nil
+	}
+	var count int64
+	err := tx.Unscoped().Model(&entity.Product{}).
+		Where("tenant_id = ? AND sku = ? AND id <> ?", product.TenantID, *product.SKU, product.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicateSKU
+	}
+	return nil
+}
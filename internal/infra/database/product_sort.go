@@ -0,0 +1,112 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidSortField     = errors.New("invalid sort field")
+	ErrUnsupportedSortField = errors.New("cursor pagination only supports sorting by created_at")
+)
+
+// productSortColumns whitelists the entity.Product fields a listing can be
+// ordered by, mapped to their columns.
+var productSortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"price":      "price_amount",
+	"created_at": "created_at",
+	"rating":     "average_rating",
+}
+
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// ProductSort is an ordered list of sort fields. An empty sort orders by
+// created_at ascending.
+type ProductSort []SortField
+
+// ParseProductSort parses a comma separated list of fields such as
+// "price,-name". A leading "-" sorts that field in descending order. The
+// legacy values "asc" and "desc" sort by created_at.
+func ParseProductSort(s string) (ProductSort, error) {
+	switch s {
+	case "", "asc":
+		return nil, nil
+	case "desc":
+		return ProductSort{{Column: "created_at", Desc: true}}, nil
+	}
+
+	var sort ProductSort
+	seen := map[string]bool{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+
+		column, ok := productSortColumns[field]
+		if !ok {
+			return nil, fmt.Errorf("%w %q, allowed fields are created_at, id, name, price and rating", ErrInvalidSortField, field)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("%w %q, field is repeated", ErrInvalidSortField, field)
+		}
+		seen[column] = true
+		sort = append(sort, SortField{Column: column, Desc: desc})
+	}
+	return sort, nil
+}
+
+// cursorDirection reports whether the sort can be walked with a
+// (created_at, id) cursor and, if so, in which direction.
+func (s ProductSort) cursorDirection() (desc bool, err error) {
+	switch {
+	case len(s) == 0:
+		return false, nil
+	case len(s) == 1 && s[0].Column == "created_at":
+		return s[0].Desc, nil
+	}
+	return false, ErrUnsupportedSortField
+}
+
+// SupportsCursor reports whether the sort can be used with FindAllAfter.
+func (s ProductSort) SupportsCursor() bool {
+	_, err := s.cursorDirection()
+	return err == nil
+}
+
+func (s ProductSort) apply(db *gorm.DB) *gorm.DB {
+	if len(s) == 0 {
+		s = ProductSort{{Column: "created_at"}}
+	}
+	tiebreaker := true
+	for _, field := range s {
+		db = db.Order(field.orderBy())
+		if field.Column == "id" {
+			tiebreaker = false
+		}
+	}
+	if tiebreaker {
+		db = db.Order("id asc")
+	}
+	return db
+}
+
+func (f SortField) orderBy() string {
+	direction := " asc"
+	if f.Desc {
+		direction = " desc"
+	}
+	// Amounts in different currencies cannot be compared, so prices are
+	// ordered within their currency.
+	if f.Column == "price_amount" {
+		return "price_currency asc, price_amount" + direction
+	}
+	return f.Column + direction
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAlreadyReviewed = errors.New("user already reviewed this product")
+	ErrReviewConflict  = errors.New("review was modified by another request")
+)
+
+type Review struct {
+	DB *gorm.DB
+}
+
+func NewReview(db *gorm.DB) *Review {
+	return &Review{DB: db}
+}
+
+// Create stores review and adds it to the rating of its product.
+func (r *Review) Create(review *entity.Review) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := findProduct(tx, review.ProductID.String()); err != nil {
+			return err
+		}
+		var existing int64
+		err := tx.Model(&entity.Review{}).
+			Where("product_id = ? AND author_id = ?", review.ProductID, review.AuthorID).
+			Count(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrAlreadyReviewed
+		}
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+		stars, count := review.Rating()
+		return addRating(tx, review.ProductID.String(), stars, count)
+	})
+}
+
+// FindByProduct lists the reviews of a product in status, or in any status
+// when it is empty, newest first.
+func (r *Review) FindByProduct(productID, status string) ([]*entity.Review, error) {
+	var reviews []*entity.Review
+	query := r.DB.Where("product_id = ?", productID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at desc").Order("id asc").Find(&reviews).Error
+	return reviews, err
+}
+
+func (r *Review) FindById(productID, id string) (*entity.Review, error) {
+	var review entity.Review
+	if err := r.DB.First(&review, "id = ? AND product_id = ?", id, productID).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// Update saves the content and status of review and moves the rating of its
+// product by the difference with the stored review. The write only applies
+// if the stored review is still the one the difference was taken from, and
+// ErrReviewConflict is returned when another request changed it meanwhile.
+func (r *Review) Update(review *entity.Review) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var stored entity.Review
+		err := tx.First(&stored, "id = ? AND product_id = ?", review.ID, review.ProductID).Error
+		if err != nil {
+			return err
+		}
+		oldStars, oldCount := stored.Rating()
+		result := tx.Model(&entity.Review{}).
+			Where("id = ? AND stars = ? AND status = ? AND updated_at = ?", stored.ID, stored.Stars, stored.Status, stored.UpdatedAt).
+			Updates(map[string]interface{}{
+				"stars":      review.Stars,
+				"title":      review.Title,
+				"body":       review.Body,
+				"status":     review.Status,
+				"updated_at": review.UpdatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrReviewConflict
+		}
+		newStars, newCount := review.Rating()
+		return addRating(tx, review.ProductID.String(), newStars-oldStars, newCount-oldCount)
+	})
+}
+
+// Delete removes a review and takes it out of the rating of its product.
+func (r *Review) Delete(productID, id string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var stored entity.Review
+		if err := tx.First(&stored, "id = ? AND product_id = ?", id, productID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&stored).Error; err != nil {
+			return err
+		}
+		stars, count := stored.Rating()
+		return addRating(tx, productID, -stars, -count)
+	})
+}
+
+// addRating moves the rating aggregates of a product by stars and count
+// without reading the other reviews. Products in the trash are updated too
+// so their rating is right if they are restored.
+func addRating(tx *gorm.DB, productID string, stars, count int) error {
+	if stars == 0 && count == 0 {
+		return nil
+	}
+	err := tx.Unscoped().Model(&entity.Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
+		"rating_sum":   gorm.Expr("rating_sum + ?", stars),
+		"review_count": gorm.Expr("review_count + ?", count),
+	}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(&entity.Product{}).Where("id = ?", productID).
+		Update("average_rating", gorm.Expr("CASE WHEN review_count > 0 THEN CAST(rating_sum AS REAL) / review_count ELSE 0 END")).Error
+}
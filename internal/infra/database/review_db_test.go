@@ -0,0 +1,148 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestReviewRatingAggregates(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.Review{})
+	productDB := NewProduct(db)
+	product, _ := entity.NewProduct("Shirt", price(2000))
+	assert.NoError(t, productDB.Create(product))
+	reviewDB := NewReview(db)
+
+	first, _ := entity.NewReview(product.ID, "user-1", 5, "Great", "")
+	second, _ := entity.NewReview(product.ID, "user-2", 2, "Meh", "")
+	assert.NoError(t, reviewDB.Create(first))
+	assert.NoError(t, reviewDB.Create(second))
+
+	again, _ := entity.NewReview(product.ID, "user-1", 1, "Changed my mind", "")
+	assert.ErrorIs(t, reviewDB.Create(again), ErrAlreadyReviewed)
+	missing, _ := entity.NewReview(entityPKG.NewId(), "user-1", 1, "Who?", "")
+	assert.ErrorIs(t, reviewDB.Create(missing), gorm.ErrRecordNotFound)
+
+	found, _ := productDB.FindById(product.ID.String())
+	assert.Equal(t, 0, found.ReviewCount)
+
+	first.Moderate(entity.ReviewApproved, time.Now())
+	second.Moderate(entity.ReviewApproved, time.Now())
+	assert.NoError(t, reviewDB.Update(first))
+	assert.NoError(t, reviewDB.Update(second))
+	found, _ = productDB.FindById(product.ID.String())
+	assert.Equal(t, 2, found.ReviewCount)
+	assert.Equal(t, 3.5, found.AverageRating)
+
+	second.Edit(4, "Better", "", time.Now())
+	assert.NoError(t, reviewDB.Update(second))
+	found, _ = productDB.FindById(product.ID.String())
+	assert.Equal(t, 1, found.ReviewCount)
+	assert.Equal(t, 5.0, found.AverageRating)
+
+	approved, err := reviewDB.FindByProduct(product.ID.String(), entity.ReviewApproved)
+	assert.NoError(t, err)
+	assert.Len(t, approved, 1)
+	all, _ := reviewDB.FindByProduct(product.ID.String(), "")
+	assert.Len(t, all, 2)
+
+	assert.NoError(t, reviewDB.Delete(product.ID.String(), first.ID.String()))
+	assert.ErrorIs(t, reviewDB.Delete(product.ID.String(), first.ID.String()), gorm.ErrRecordNotFound)
+	found, _ = productDB.FindById(product.ID.String())
+	assert.Equal(t, 0, found.ReviewCount)
+	assert.Equal(t, 0.0, found.AverageRating)
+}
+
+func TestProductUpdateKeepsRating(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.Review{})
+	productDB := NewProduct(db)
+	product, _ := entity.NewProduct("Shirt", price(2000))
+	assert.NoError(t, productDB.Create(product))
+	review, _ := entity.NewReview(product.ID, "user-1", 4, "Good", "")
+	review.Status = entity.ReviewApproved
+	assert.NoError(t, NewReview(db).Create(review))
+
+	product.Name = "Blue shirt"
+	assert.NoError(t, productDB.Update(product))
+	found, _ := productDB.FindById(product.ID.String())
+	assert.Equal(t, "Blue shirt", found.Name)
+	assert.Equal(t, 1, found.ReviewCount)
+	assert.Equal(t, 4.0, found.AverageRating)
+}
+
+func TestFindProductsByRating(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.Review{})
+	productDB := NewProduct(db)
+	reviewDB := NewReview(db)
+	for i, stars := range []int{0, 3, 5} {
+		product, _ := entity.NewProduct("Product", price(1000))
+		product.CreatedAt = product.CreatedAt.Add(time.Duration(i) * time.Second)
+		assert.NoError(t, productDB.Create(product))
+		if stars == 0 {
+			continue
+		}
+		review, _ := entity.NewReview(product.ID, "user-1", stars, "Review", "")
+		review.Status = entity.ReviewApproved
+		assert.NoError(t, reviewDB.Create(review))
+	}
+
+	minRating := 3.0
+	products, err := productDB.FindAll(0, 0, nil, ProductFilter{MinRating: &minRating})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+
+	sort, err := ParseProductSort("-rating")
+	assert.NoError(t, err)
+	products, err = productDB.FindAll(0, 0, sort, ProductFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, products[0].AverageRating)
+	assert.Equal(t, 3.0, products[1].AverageRating)
+	assert.Equal(t, 0, products[2].ReviewCount)
+}
+
+func TestReviewUpdateConflict(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.Review{})
+	productDB := NewProduct(db)
+	product, _ := entity.NewProduct("Shirt", price(2000))
+	assert.NoError(t, productDB.Create(product))
+	reviewDB := NewReview(db)
+	review, _ := entity.NewReview(product.ID, "user-1", 5, "Great", "")
+	assert.NoError(t, reviewDB.Create(review))
+
+	// Another request approves the review between the read and the write
+	// of the update.
+	moderated := false
+	db.Callback().Update().Before("gorm:update").Register("test:moderate", func(tx *gorm.DB) {
+		if moderated || tx.Statement.Table != "reviews" {
+			return
+		}
+		moderated = true
+		tx.Session(&gorm.Session{NewDB: true}).Exec("UPDATE reviews SET status = ? WHERE id = ?", entity.ReviewApproved, review.ID)
+	})
+
+	review.Edit(1, "Broke", "", time.Now())
+	assert.ErrorIs(t, reviewDB.Update(review), ErrReviewConflict)
+	found, _ := productDB.FindById(product.ID.String())
+	assert.Equal(t, 0, found.ReviewCount)
+}
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientStock    = errors.New("not enough stock available")
+	ErrReservationNotActive = errors.New("reservation is no longer active")
+	ErrReservationNotFound  = errors.New("reservation not found")
+)
+
+// Stock keeps the stock ledger of products and its projection, the stock
+// levels. Every change to a level is a single conditional UPDATE, so
+// concurrent requests can never take more units than are available.
+type Stock struct {
+	DB *gorm.DB
+}
+
+func NewStock(db *gorm.DB) *Stock {
+	return &Stock{DB: db}
+}
+
+// Adjust records movement in the ledger and applies it to the stock level.
+// Movements that take units away fail with ErrInsufficientStock rather than
+// eat into reserved units.
+func (s *Stock) Adjust(movement *entity.StockMovement) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		productID := movement.ProductID.String()
+		if err := prepareStockLevel(tx, productID); err != nil {
+			return err
+		}
+
+		query := tx.Model(&entity.StockLevel{}).Where("product_id = ?", productID)
+		if movement.Quantity < 0 {
+			query = query.Where("on_hand - reserved + ? >= 0", movement.Quantity)
+		}
+		result := query.Update("on_hand", gorm.Expr("on_hand + ?", movement.Quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientStock
+		}
+		return tx.Create(movement).Error
+	})
+}
+
+// FindLevel returns the stock level of a product, which is zero for products
+// that never had stock.
+func (s *Stock) FindLevel(productID string) (*entity.StockLevel, error) {
+	var level *entity.StockLevel
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := prepareStockLevel(tx, productID); err != nil {
+			return err
+		}
+		level = &entity.StockLevel{}
+		return tx.First(level, "product_id = ?", productID).Error
+	})
+	return level, err
+}
+
+// FindMovements lists the ledger of a product, oldest first.
+func (s *Stock) FindMovements(productID string) ([]*entity.StockMovement, error) {
+	var movements []*entity.StockMovement
+	err := s.DB.Where("product_id = ?", productID).Order("created_at asc").Order("id asc").Find(&movements).Error
+	return movements, err
+}
+
+// Reserve holds reservation.Quantity units of the product until the
+// reservation expires, failing with ErrInsufficientStock when they are not
+// available.
+func (s *Stock) Reserve(reservation *entity.Reservation) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		productID := reservation.ProductID.String()
+		if err := prepareStockLevel(tx, productID); err != nil {
+			return err
+		}
+
+		result := tx.Model(&entity.StockLevel{}).
+			Where("product_id = ? AND on_hand - reserved >= ?", productID, reservation.Quantity).
+			Update("reserved", gorm.Expr("reserved + ?", reservation.Quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientStock
+		}
+		return tx.Create(reservation).Error
+	})
+}
+
+// FindReservation returns a reservation of a product, or
+// ErrReservationNotFound when it has none with that id.
+func (s *Stock) FindReservation(productID, reservationID string) (*entity.Reservation, error) {
+	var reservation entity.Reservation
+	err := s.DB.First(&reservation, "id = ? AND product_id = ?", reservationID, productID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Release gives the units of an active reservation back.
+func (s *Stock) Release(productID, reservationID string) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var reservation entity.Reservation
+		err := tx.First(&reservation, "id = ? AND product_id = ?", reservationID, productID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrReservationNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := expireReservations(tx, tx.Where("id = ?", reservationID), time.Now()); err != nil {
+			return err
+		}
+		return endReservation(tx, &reservation, entity.ReservationReleased)
+	})
+}
+
+// ExpireReservations releases every active reservation that expired before
+// now and returns how many there were.
+func (s *Stock) ExpireReservations(now time.Time) (int64, error) {
+	var expired int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		expired, err = expireReservations(tx, tx, now)
+		return err
+	})
+	return expired, err
+}
+
+// prepareStockLevel makes sure the product exists and has a stock level row,
+// and gives the units of its expired reservations back before the level is
+// read or changed.
+func prepareStockLevel(tx *gorm.DB, productID string) error {
+	product, err := findProduct(tx, productID)
+	if err != nil {
+		return err
+	}
+	err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.StockLevel{ProductID: product.ID}).Error
+	if err != nil {
+		return err
+	}
+	_, err = expireReservations(tx, tx.Where("product_id = ?", productID), time.Now())
+	return err
+}
+
+// expireReservations ends the active reservations found by query that
+// expired before now.
+func expireReservations(tx, query *gorm.DB, now time.Time) (int64, error) {
+	var reservations []*entity.Reservation
+	// expires_at is compared as text by sqlite, see ProductFilter.
+	err := query.Where("status = ? AND expires_at <= ?", entity.ReservationActive, now.Local()).Find(&reservations).Error
+	if err != nil {
+		return 0, err
+	}
+	var expired int64
+	for _, reservation := range reservations {
+		err := endReservation(tx, reservation, entity.ReservationExpired)
+		if errors.Is(err, ErrReservationNotActive) {
+			continue
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// endReservation moves an active reservation to status and returns its units
+// to the stock level. The status check is part of the UPDATE, so a
+// reservation that is ended twice concurrently only returns its units once.
+func endReservation(tx *gorm.DB, reservation *entity.Reservation, status string) error {
+	result := tx.Model(&entity.Reservation{}).
+		Where("id = ? AND status = ?", reservation.ID, entity.ReservationActive).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReservationNotActive
+	}
+	reservation.Status = status
+	return tx.Model(&entity.StockLevel{}).
+		Where("product_id = ?", reservation.ProductID).
+		Update("reserved", gorm.Expr("reserved - ?", reservation.Quantity)).Error
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newStockTestDB(t *testing.T) (*gorm.DB, *entity.Product) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	// Every connection to file::memory: gets its own database.
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Error(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.StockLevel{}, &entity.StockMovement{}, &entity.Reservation{})
+
+	product, err := entity.NewProduct("Product 1", price(1000))
+	assert.NoError(t, err)
+	assert.NoError(t, NewProduct(db).Create(product))
+	return db, product
+}
+
+func TestAdjustStock(t *testing.T) {
+	db, product := newStockTestDB(t)
+	stockDB := NewStock(db)
+
+	level, err := stockDB.FindLevel(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, level.OnHand)
+
+	adjust := func(kind string, quantity int) error {
+		movement, err := entity.NewStockMovement(product.ID, kind, quantity, "", "user")
+		assert.NoError(t, err)
+		return stockDB.Adjust(movement)
+	}
+	assert.NoError(t, adjust(entity.StockReceived, 10))
+	assert.NoError(t, adjust(entity.StockSold, 4))
+	assert.NoError(t, adjust(entity.StockCorrected, -1))
+	assert.ErrorIs(t, adjust(entity.StockSold, 6), ErrInsufficientStock)
+
+	level, err = stockDB.FindLevel(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 5, level.OnHand)
+	assert.Equal(t, 5, level.Available())
+
+	movements, err := stockDB.FindMovements(product.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, movements, 3)
+	assert.Equal(t, -4, movements[1].Quantity)
+
+	_, err = stockDB.FindLevel(entity.Product{}.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestReserveStock(t *testing.T) {
+	db, product := newStockTestDB(t)
+	stockDB := NewStock(db)
+
+	movement, _ := entity.NewStockMovement(product.ID, entity.StockReceived, 3, "", "user")
+	assert.NoError(t, stockDB.Adjust(movement))
+
+	first, _ := entity.NewReservation(product.ID, 2, "user", time.Minute)
+	assert.NoError(t, stockDB.Reserve(first))
+	second, _ := entity.NewReservation(product.ID, 2, "user", time.Minute)
+	assert.ErrorIs(t, stockDB.Reserve(second), ErrInsufficientStock)
+
+	sell, _ := entity.NewStockMovement(product.ID, entity.StockSold, 2, "", "user")
+	assert.ErrorIs(t, stockDB.Adjust(sell), ErrInsufficientStock)
+
+	level, err := stockDB.FindLevel(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, level.OnHand)
+	assert.Equal(t, 2, level.Reserved)
+	assert.Equal(t, 1, level.Available())
+
+	assert.NoError(t, stockDB.Release(product.ID.String(), first.ID.String()))
+	assert.ErrorIs(t, stockDB.Release(product.ID.String(), first.ID.String()), ErrReservationNotActive)
+	assert.ErrorIs(t, stockDB.Release(product.ID.String(), second.ID.String()), ErrReservationNotFound)
+	assert.NoError(t, stockDB.Reserve(second))
+}
+
+func TestExpireReservations(t *testing.T) {
+	db, product := newStockTestDB(t)
+	stockDB := NewStock(db)
+
+	movement, _ := entity.NewStockMovement(product.ID, entity.StockReceived, 1, "", "user")
+	assert.NoError(t, stockDB.Adjust(movement))
+
+	reservation, _ := entity.NewReservation(product.ID, 1, "user", time.Minute)
+	assert.NoError(t, stockDB.Reserve(reservation))
+
+	expired, err := stockDB.ExpireReservations(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), expired)
+
+	expired, err = stockDB.ExpireReservations(time.Now().Add(2 * time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), expired)
+
+	level, err := stockDB.FindLevel(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, level.Reserved)
+
+	// Reservations that are past their expiry are given back even before the
+	// sweep gets to them.
+	db.Model(&entity.Reservation{}).Where("id = ?", reservation.ID).
+		Updates(map[string]interface{}{"status": entity.ReservationActive, "expires_at": time.Now().Add(-time.Second)})
+	db.Model(&entity.StockLevel{}).Where("product_id = ?", product.ID).Update("reserved", 1)
+	other, _ := entity.NewReservation(product.ID, 1, "user", time.Minute)
+	assert.NoError(t, stockDB.Reserve(other))
+}
+
+func TestReserveLastUnitConcurrently(t *testing.T) {
+	db, product := newStockTestDB(t)
+	stockDB := NewStock(db)
+
+	movement, _ := entity.NewStockMovement(product.ID, entity.StockReceived, 1, "", "user")
+	assert.NoError(t, stockDB.Adjust(movement))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reservation, _ := entity.NewReservation(product.ID, 1, "user", time.Minute)
+			errs <- stockDB.Reserve(reservation)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	reserved := 0
+	for err := range errs {
+		if err == nil {
+			reserved++
+		} else {
+			assert.ErrorIs(t, err, ErrInsufficientStock)
+		}
+	}
+	assert.Equal(t, 1, reserved)
+
+	level, err := stockDB.FindLevel(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, level.Reserved)
+	assert.Equal(t, 0, level.Available())
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDuplicateSKU            = errors.New("sku is already in use")
+	ErrDuplicateVariantOptions = errors.New("product already has a variant with these options")
+)
+
+type Variant struct {
+	DB *gorm.DB
+}
+
+func NewVariant(db *gorm.DB) *Variant {
+	return &Variant{DB: db}
+}
+
+func (v *Variant) Create(variant *entity.Variant) error {
+	return v.DB.Transaction(func(tx *gorm.DB) error {
+		product, err := findProduct(tx, variant.ProductID.String())
+		if err != nil {
+			return err
+		}
+		if err := variant.ValidateFor(product); err != nil {
+			return err
+		}
+		variant.TenantID = product.TenantID
+		if err := checkVariantUnique(tx, variant); err != nil {
+			return err
+		}
+		return tx.Create(variant).Error
+	})
+}
+
+// FindByProduct lists the variants of a product in the order they were
+// created.
+func (v *Variant) FindByProduct(productID string) ([]*entity.Variant, error) {
+	var variants []*entity.Variant
+	err := v.DB.Where("product_id = ?", productID).Order("created_at asc").Order("id asc").Find(&variants).Error
+	return variants, err
+}
+
+func (v *Variant) FindById(productID, id string) (*entity.Variant, error) {
+	var variant entity.Variant
+	if err := v.DB.First(&variant, "id = ? AND product_id = ?", id, productID).Error; err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (v *Variant) Update(variant *entity.Variant) error {
+	return v.DB.Transaction(func(tx *gorm.DB) error {
+		var stored entity.Variant
+		if err := tx.First(&stored, "id = ? AND product_id = ?", variant.ID, variant.ProductID).Error; err != nil {
+			return err
+		}
+		product, err := findProduct(tx.Unscoped(), variant.ProductID.String())
+		if err != nil {
+			return err
+		}
+		if err := variant.ValidateFor(product); err != nil {
+			return err
+		}
+		variant.TenantID = stored.TenantID
+		if err := checkVariantUnique(tx, variant); err != nil {
+			return err
+		}
+
+		// A nil price must clear both price columns, which Updates with a
+		// struct would skip.
+		columns := map[string]interface{}{
+			"sku":            variant.SKU,
+			"options":        variant.Options,
+			"option_key":     variant.OptionKey,
+			"price_amount":   nil,
+			"price_currency": nil,
+		}
+		if variant.Price != nil {
+			columns["price_amount"] = variant.Price.Amount
+			columns["price_currency"] = variant.Price.Currency
+		}
+		result := tx.Model(&entity.Variant{}).
+			Where("id = ? AND product_id = ?", variant.ID, variant.ProductID).
+			Updates(columns)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (v *Variant) Delete(productID, id string) error {
+	result := v.DB.Delete(&entity.Variant{}, "id = ? AND product_id = ?", id, productID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// BackfillTenants moves variants created before they had a tenant to the
+// tenant of their product, dropping the index that kept SKUs unique across
+// tenants, and returns how many it moved.
+func (v *Variant) BackfillTenants() (int64, error) {
+	migrator := v.DB.Migrator()
+	if migrator.HasIndex(&entity.Variant{}, "idx_variants_sku") {
+		if err := migrator.DropIndex(&entity.Variant{}, "idx_variants_sku"); err != nil {
+			return 0, err
+		}
+	}
+	result := v.DB.Model(&entity.Variant{}).
+		Where("tenant_id = ''").
+		Where("EXISTS (SELECT 1 FROM products WHERE products.id = variants.product_id AND products.tenant_id <> '')").
+		Update("tenant_id", gorm.Expr("(SELECT tenant_id FROM products WHERE products.id = variants.product_id)"))
+	return result.RowsAffected, result.Error
+}
+
+// checkVariantUnique reports which of the unique indexes of variants the
+// variant would break, so callers get a meaningful error instead of a
+// driver specific constraint violation.
+func checkVariantUnique(tx *gorm.DB, variant *entity.Variant) error {
+	var count int64
+	err := tx.Model(&entity.Variant{}).
+		Where("tenant_id = ? AND sku = ? AND id <> ?", variant.TenantID, variant.SKU, variant.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicateSKU
+	}
+
+	err = tx.Model(&entity.Variant{}).
+		Where("product_id = ? AND option_key = ? AND id <> ?", variant.ProductID, variant.OptionKey, variant.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicateVariantOptions
+	}
+	return nil
+}
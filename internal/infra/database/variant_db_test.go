@@ -0,0 +1,134 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCreateVariants(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.Variant{})
+	product, _ := entity.NewProduct("Shirt", price(2000))
+	assert.NoError(t, NewProduct(db).Create(product))
+	variantDB := NewVariant(db)
+
+	override := price(2500)
+	blue, err := entity.NewVariant(product.ID, "SHIRT-M-BLUE", &override, map[string]string{"size": "M", "color": "blue"})
+	assert.NoError(t, err)
+	assert.NoError(t, variantDB.Create(blue))
+
+	sameSKU, _ := entity.NewVariant(product.ID, "SHIRT-M-BLUE", nil, map[string]string{"size": "L", "color": "blue"})
+	assert.ErrorIs(t, variantDB.Create(sameSKU), ErrDuplicateSKU)
+
+	sameOptions, _ := entity.NewVariant(product.ID, "SHIRT-M-BLUE-2", nil, map[string]string{"color": "Blue", "size": "m"})
+	assert.ErrorIs(t, variantDB.Create(sameOptions), ErrDuplicateVariantOptions)
+
+	red, _ := entity.NewVariant(product.ID, "SHIRT-M-RED", nil, map[string]string{"size": "M", "color": "red"})
+	assert.NoError(t, variantDB.Create(red))
+
+	dollars := entityPKG.Money{Amount: 500, Currency: "USD"}
+	foreign, _ := entity.NewVariant(product.ID, "SHIRT-S-RED", &dollars, map[string]string{"size": "S", "color": "red"})
+	assert.ErrorIs(t, variantDB.Create(foreign), entity.ErrVariantCurrency)
+	red.Price = &dollars
+	assert.ErrorIs(t, variantDB.Update(red), entity.ErrVariantCurrency)
+	red.Price = nil
+
+	orphan, _ := entity.NewVariant(entityPKG.NewId(), "ORPHAN", nil, map[string]string{"size": "M"})
+	assert.ErrorIs(t, variantDB.Create(orphan), gorm.ErrRecordNotFound)
+
+	variants, err := variantDB.FindByProduct(product.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, variants, 2)
+	assert.Equal(t, &override, variants[0].Price)
+	assert.Equal(t, entity.VariantOptions{"size": "M", "color": "blue"}, variants[0].Options)
+	assert.Nil(t, variants[1].Price)
+}
+
+func TestUpdateAndDeleteVariant(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.Variant{})
+	product, _ := entity.NewProduct("Shirt", price(2000))
+	assert.NoError(t, NewProduct(db).Create(product))
+	variantDB := NewVariant(db)
+
+	override := price(2500)
+	blue, _ := entity.NewVariant(product.ID, "SHIRT-M-BLUE", &override, map[string]string{"size": "M", "color": "blue"})
+	red, _ := entity.NewVariant(product.ID, "SHIRT-M-RED", nil, map[string]string{"size": "M", "color": "red"})
+	assert.NoError(t, variantDB.Create(blue))
+	assert.NoError(t, variantDB.Create(red))
+
+	assert.NoError(t, red.Set("SHIRT-M-BLUE", nil, red.Options))
+	assert.ErrorIs(t, variantDB.Update(red), ErrDuplicateSKU)
+
+	assert.NoError(t, blue.Set("SHIRT-L-BLUE", nil, map[string]string{"size": "L", "color": "blue"}))
+	assert.NoError(t, variantDB.Update(blue))
+
+	found, err := variantDB.FindById(product.ID.String(), blue.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "SHIRT-L-BLUE", found.SKU)
+	assert.Nil(t, found.Price)
+	assert.Equal(t, "L", found.Options["size"])
+
+	assert.NoError(t, variantDB.Delete(product.ID.String(), blue.ID.String()))
+	assert.ErrorIs(t, variantDB.Delete(product.ID.String(), blue.ID.String()), gorm.ErrRecordNotFound)
+}
+
+func TestVariantSKUsPerTenant(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.Variant{})
+	shirtA, _ := entity.NewProduct("Shirt", price(2000))
+	shirtB, _ := entity.NewProduct("Shirt", price(2000))
+	assert.NoError(t, NewProduct(db).ForTenant("tenant-a").Create(shirtA))
+	assert.NoError(t, NewProduct(db).ForTenant("tenant-b").Create(shirtB))
+	variantDB := NewVariant(db)
+
+	blueA, _ := entity.NewVariant(shirtA.ID, "SHIRT-M-BLUE", nil, map[string]string{"size": "M"})
+	assert.NoError(t, variantDB.Create(blueA))
+	assert.Equal(t, "tenant-a", blueA.TenantID)
+	blueB, _ := entity.NewVariant(shirtB.ID, "SHIRT-M-BLUE", nil, map[string]string{"size": "M"})
+	assert.NoError(t, variantDB.Create(blueB))
+
+	redB, _ := entity.NewVariant(shirtB.ID, "SHIRT-L-RED", nil, map[string]string{"size": "L"})
+	assert.NoError(t, variantDB.Create(redB))
+	redB.SKU = "SHIRT-M-BLUE"
+	assert.ErrorIs(t, variantDB.Update(redB), ErrDuplicateSKU)
+}
+
+func TestBackfillVariantTenants(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.Variant{})
+	db.Exec("CREATE UNIQUE INDEX idx_variants_sku ON variants(sku)")
+	product, _ := entity.NewProduct("Shirt", price(2000))
+	assert.NoError(t, NewProduct(db).ForTenant("tenant-a").Create(product))
+	legacy, _ := entity.NewVariant(product.ID, "SHIRT-M-BLUE", nil, map[string]string{"size": "M"})
+	db.Create(legacy)
+	variantDB := NewVariant(db)
+
+	backfilled, err := variantDB.BackfillTenants()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), backfilled)
+	assert.False(t, db.Migrator().HasIndex(&entity.Variant{}, "idx_variants_sku"))
+	found, _ := variantDB.FindById(product.ID.String(), legacy.ID.String())
+	assert.Equal(t, "tenant-a", found.TenantID)
+
+	backfilled, err = variantDB.BackfillTenants()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), backfilled)
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorage(t *testing.T) {
+	local, err := NewLocal(t.TempDir())
+	assert.NoError(t, err)
+
+	assert.NoError(t, local.Put("products/1/original", strings.NewReader("image")))
+
+	file, err := local.Get("products/1/original")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(file)
+	file.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "image", string(content))
+
+	assert.NoError(t, local.Delete("products/1/original"))
+	assert.NoError(t, local.Delete("products/1/original"))
+	_, err = local.Get("products/1/original")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+}
+
+func TestLocalStorageRejectsInvalidKeys(t *testing.T) {
+	local, err := NewLocal(t.TempDir())
+	assert.NoError(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a//b"} {
+		assert.ErrorIs(t, local.Put(key, strings.NewReader("x")), ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	ErrBlobNotFound = errors.New("blob not found")
+	ErrInvalidKey   = errors.New("invalid blob key")
+)
+
+// BlobStorage stores opaque files under slash separated keys such as
+// "products/<id>/images/<id>/original".
+type BlobStorage interface {
+	Put(key string, r io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/jwtauth"
+)
+
+var errNotProductOwner = errors.New("only the owner of the product or an admin can change it")
+
+func claimString(r *http.Request, name string) string {
+	_, claims, _ := jwtauth.FromContext(r.Context())
+	value, _ := claims[name].(string)
+	return value
+}
+
+func isAdmin(r *http.Request) bool {
+	return claimString(r, "role") == entity.RoleAdmin
+}
+
+// AdminOnly rejects requests whose JWT was not issued to an admin. It must
+// run after jwtauth.Verifier and jwtauth.Authenticator.
+func AdminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(r) {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Message: "admin role required"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ProductInTenant answers 404 to requests on the sub-resources of a product,
+// such as its variants, unless the product, in the trash or not, belongs to
+// the tenant of the JWT. Invalid IDs are left for the handler to reject. It
+// must run after jwtauth.Verifier and jwtauth.Authenticator, on routes with
+// an {id} parameter.
+func ProductInTenant(productDB database.ProductInterface) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := chi.URLParam(r, "id")
+			if _, err := entityPKG.ParseID(id); err == nil {
+				tenantDB := productDB.ForTenant(claimString(r, "tid"))
+				if _, err := tenantDB.FindById(id); err != nil {
+					if _, err := tenantDB.FindDeletedById(id); err != nil {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requester is who a request was made by, kept apart from the request so
+// work that outlives it, such as imports, can still check permissions.
+type requester struct {
+	userID   string
+	tenantID string
+	admin    bool
+}
+
+func requesterOf(r *http.Request) requester {
+	return requester{userID: claimString(r, "sub"), tenantID: claimString(r, "tid"), admin: isAdmin(r)}
+}
+
+// canModify reports whether the requester may change or delete product.
+func (rq requester) canModify(product *entity.Product) bool {
+	return rq.admin || product.IsOwnedBy(rq.userID)
+}
+
+// checkProductOwner writes a 403 response and returns false when the user
+// making the request may not change product.
+func checkProductOwner(w http.ResponseWriter, r *http.Request, product *entity.Product) bool {
+	if !requesterOf(r).canModify(product) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: errNotProductOwner.Error()})
+		return false
+	}
+	return true
+}
+
+// checkProductOwnerByID loads the product of a sub-resource request, in the
+// trash or not, and writes a 404 or 403 response and returns false when it
+// is not in the tenant of the JWT or the user making the request may not
+// change it.
+func checkProductOwnerByID(w http.ResponseWriter, r *http.Request, productDB database.ProductInterface, id string) bool {
+	product, err := findTenantProduct(r, productDB, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return false
+	}
+	return checkProductOwner(w, r, product)
+}
+
+// findTenantProduct loads a product of the tenant of the JWT, in the trash
+// or not.
+func findTenantProduct(r *http.Request, productDB database.ProductInterface, id string) (*entity.Product, error) {
+	tenantDB := productDB.ForTenant(claimString(r, "tid"))
+	product, err := tenantDB.FindById(id)
+	if err != nil {
+		return tenantDB.FindDeletedById(id)
+	}
+	return product, nil
+}
@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	"gorm.io/gorm"
+)
+
+// maxBatchOperations is the largest number of operations a batch accepts.
+const maxBatchOperations = 100
+
+const (
+	batchCreate = "create"
+	batchUpdate = "update"
+	batchDelete = "delete"
+)
+
+var (
+	errBatchFailed     = errors.New("batch operation failed")
+	errBatchRolledBack = errors.New("not applied, another operation of the batch failed")
+	errBatchIDRequired = errors.New("id is required")
+	errBatchVersion    = errors.New("version is required")
+	errBatchProduct    = errors.New("product is required")
+	errInvalidBatchOp  = errors.New("op must be create, update or delete")
+)
+
+// Batch Products godoc
+// @Summary Create, update and delete products in one transaction
+// @Description Run up to 100 operations in order inside one transaction. Updates and deletes follow the single product routes, so only the owner of a product or an admin can apply them. By default the batch is all or nothing: the first failing operation rolls everything back, and the other operations are reported with status 424. With continue_on_error, failing operations are rolled back on their own, the others are committed, and every result is reported; if any operation failed the response is 207 Multi-Status. failed counts the operations that were not applied.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param request body dto.BatchInput true "batch request"
+// @Success 200 {object} dto.BatchOutput
+// @Success 207 {object} dto.BatchOutput
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} dto.BatchOutput
+// @Failure 500 {object} ErrorResponse
+// @Router /products/batch [post]
+// @Security ApiKeyAuth
+func (ph *ProductHandler) BatchProducts(w http.ResponseWriter, r *http.Request) {
+	var input dto.BatchInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if len(input.Operations) == 0 || len(input.Operations) > maxBatchOperations {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: fmt.Sprintf("a batch needs between 1 and %d operations", maxBatchOperations)})
+		return
+	}
+
+	rq := requesterOf(r)
+	results := make([]dto.BatchOperationResult, len(input.Operations))
+	failed := -1
+	err := ph.productDB(r).Transaction(func(tx database.ProductInterface) error {
+		for i, op := range input.Operations {
+			// Each operation runs in a savepoint, so a failing one leaves no
+			// partial writes behind when the batch continues.
+			err := tx.Transaction(func(opTx database.ProductInterface) error {
+				results[i] = runBatchOperation(opTx, rq, i, op)
+				if results[i].Error != "" {
+					return errBatchFailed
+				}
+				return nil
+			})
+			if err != nil && !errors.Is(err, errBatchFailed) {
+				return err
+			}
+			if results[i].Error != "" && !input.ContinueOnError {
+				failed = i
+				return errBatchFailed
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatchFailed) {
+		fmt.Println("error to commit batch", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if failed >= 0 {
+		status = http.StatusUnprocessableEntity
+		for i := range results {
+			if i != failed {
+				results[i] = dto.BatchOperationResult{
+					Index:  i,
+					Op:     input.Operations[i].Op,
+					Status: http.StatusFailedDependency,
+					Error:  errBatchRolledBack.Error(),
+				}
+			}
+		}
+	}
+
+	output := dto.BatchOutput{Committed: failed < 0, Results: results}
+	for _, result := range results {
+		if result.Error != "" {
+			output.Failed++
+		}
+	}
+	if output.Committed && output.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(output)
+}
+
+// runBatchOperation applies op on behalf of rq with the same rules as the
+// single product routes and reports the outcome. It never returns an error:
+// failures are part of the result.
+func runBatchOperation(productDB database.ProductInterface, rq requester, index int, op dto.BatchOperationInput) dto.BatchOperationResult {
+	result := dto.BatchOperationResult{Index: index, Op: op.Op}
+	fail := func(status int, err error) dto.BatchOperationResult {
+		result.Status = status
+		result.Error = err.Error()
+		return result
+	}
+
+	switch op.Op {
+	case batchCreate:
+		if op.Product == nil {
+			return fail(http.StatusBadRequest, errBatchProduct)
+		}
+		product, err := entity.NewProduct(op.Product.Name, op.Product.Price)
+		if err != nil {
+			return fail(http.StatusBadRequest, err)
+		}
+		product.CategoryID = op.Product.CategoryID
+		product.OwnerID = rq.userID
+		product.SetTags(op.Product.Tags)
+		product.Slug = op.Product.Slug
+		product.SetSKU(op.Product.SKU)
+		if err := product.Validate(); err != nil {
+			return fail(http.StatusBadRequest, err)
+		}
+		if err := productDB.Create(product); err != nil {
+			return fail(batchWriteStatus(err))
+		}
+		result.Status = http.StatusCreated
+		result.ID = &product.ID
+		result.Version = product.Version
+		return result
+
+	case batchUpdate, batchDelete:
+		if op.ID == nil {
+			return fail(http.StatusBadRequest, errBatchIDRequired)
+		}
+		if op.Version == nil {
+			return fail(http.StatusPreconditionRequired, errBatchVersion)
+		}
+		if op.Op == batchUpdate && op.Product == nil {
+			return fail(http.StatusBadRequest, errBatchProduct)
+		}
+		result.ID = op.ID
+
+		product, err := productDB.FindById(op.ID.String())
+		if err != nil {
+			return fail(batchWriteStatus(err))
+		}
+		if !rq.canModify(product) {
+			return fail(http.StatusForbidden, errNotProductOwner)
+		}
+		if product.Version != *op.Version {
+			return fail(http.StatusPreconditionFailed, errStaleVersion)
+		}
+
+		if op.Op == batchDelete {
+			if err := productDB.Delete(product.ID.String(), product.Version); err != nil {
+				return fail(batchWriteStatus(err))
+			}
+			result.Status = http.StatusOK
+			return result
+		}
+
+		product.Name = op.Product.Name
+		product.Price = op.Product.Price
+		product.CategoryID = op.Product.CategoryID
+		product.SetTags(op.Product.Tags)
+		if op.Product.Slug != "" {
+			product.Slug = op.Product.Slug
+		}
+		product.SetSKU(op.Product.SKU)
+		if err := product.Validate(); err != nil {
+			return fail(http.StatusBadRequest, err)
+		}
+		if err := productDB.Update(product); err != nil {
+			return fail(batchWriteStatus(err))
+		}
+		result.Status = http.StatusOK
+		result.Version = product.Version
+		return result
+	}
+
+	return fail(http.StatusBadRequest, errInvalidBatchOp)
+}
+
+// batchWriteStatus maps repository errors like handleWriteError does for
+// the single product routes.
+func batchWriteStatus(err error) (int, error) {
+	switch {
+	case errors.Is(err, database.ErrVersionConflict):
+		return http.StatusPreconditionFailed, errStaleVersion
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound, errors.New("product not found")
+	case errors.Is(err, database.ErrCategoryNotFound):
+		return http.StatusUnprocessableEntity, err
+	case errors.Is(err, database.ErrDuplicateSlug), errors.Is(err, database.ErrDuplicateSKU):
+		return http.StatusConflict, err
+	}
+	fmt.Println("error to write product", err)
+	return http.StatusInternalServerError, errors.New("internal error")
+}
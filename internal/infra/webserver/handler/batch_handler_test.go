@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchProductsPartialFailure(t *testing.T) {
+	db := newTestDB(t, &entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{}, &entity.Category{})
+	handler := NewProductHandler(database.NewProduct(db), nil, nil, nil, 0)
+
+	batch := func(body string) (int, dto.BatchOutput) {
+		r := httptest.NewRequest(http.MethodPost, "/products/batch", strings.NewReader(body))
+		w := serveAs(http.HandlerFunc(handler.BatchProducts), r, map[string]interface{}{"sub": "owner", "tid": "tenant-1", "role": entity.RoleUser})
+		var output dto.BatchOutput
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&output))
+		return w.Code, output
+	}
+
+	status, output := batch(`{"continue_on_error":true,"operations":[
+		{"op":"create","product":{"name":"Shirt","price":{"amount":"10.00","currency":"BRL"}}},
+		{"op":"create","product":{"name":"","price":{"amount":"10.00","currency":"BRL"}}}]}`)
+	assert.Equal(t, http.StatusMultiStatus, status)
+	assert.True(t, output.Committed)
+	assert.Equal(t, 1, output.Failed)
+	assert.Equal(t, http.StatusCreated, output.Results[0].Status)
+	assert.Equal(t, http.StatusBadRequest, output.Results[1].Status)
+
+	status, output = batch(`{"continue_on_error":true,"operations":[
+		{"op":"create","product":{"name":"Hat","price":{"amount":"10.00","currency":"BRL"}}}]}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, output.Committed)
+	assert.Equal(t, 0, output.Failed)
+
+	status, output = batch(`{"operations":[
+		{"op":"create","product":{"name":"Cap","price":{"amount":"10.00","currency":"BRL"}}},
+		{"op":"create","product":{"name":"","price":{"amount":"10.00","currency":"BRL"}}}]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.False(t, output.Committed)
+	assert.Equal(t, 2, output.Failed)
+}
@@ -0,0 +1,354 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/go-chi/chi"
+	"gorm.io/gorm"
+)
+
+var errCartTooLarge = fmt.Errorf("a cart holds at most %d products", maxOrderItems)
+
+type CartHandler struct {
+	CartDB          database.CartInterface
+	ProductDB       database.ProductInterface
+	PriceScheduleDB database.PriceScheduleInterface
+}
+
+func NewCartHandler(cartDB database.CartInterface, productDB database.ProductInterface, priceScheduleDB database.PriceScheduleInterface) *CartHandler {
+	return &CartHandler{
+		CartDB:          cartDB,
+		ProductDB:       productDB,
+		PriceScheduleDB: priceScheduleDB,
+	}
+}
+
+// cartDB returns the repository bound to the organization of the user
+// making the request.
+func (ch *CartHandler) cartDB(r *http.Request) database.CartInterface {
+	return ch.CartDB.ForTenant(claimString(r, "tid"))
+}
+
+func (ch *CartHandler) productDB(r *http.Request) database.ProductInterface {
+	return ch.ProductDB.ForTenant(claimString(r, "tid"))
+}
+
+// Get Cart godoc
+// @Summary Get my cart
+// @Description Get the cart of the user making the request, priced at the current price of its products. Lines are flagged as deleted when their product was removed or is no longer published and as repriced when its price changed since it was added. The total leaves deleted lines out and is omitted when products use more than one currency.
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Success 200 {object} entity.Cart
+// @Failure 500 {object} ErrorResponse
+// @Router /cart [get]
+// @Security ApiKeyAuth
+func (ch *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+	ch.writeCart(w, r)
+}
+
+// Add Cart Item godoc
+// @Summary Add a product to my cart
+// @Description Add units of a published product to the cart. Adding a product that is already in the cart increases its quantity and takes its current price as the added price.
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param request body dto.CartItemInput true "cart item request"
+// @Success 200 {object} entity.Cart
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cart/items [post]
+// @Security ApiKeyAuth
+func (ch *CartHandler) AddCartItem(w http.ResponseWriter, r *http.Request) {
+	var input dto.CartItemInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if input.Quantity <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: entity.ErrInvalidQuantity.Error()})
+		return
+	}
+
+	productID := input.ProductID.String()
+	product, err := ch.productDB(r).FindById(productID)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: fmt.Sprintf("%s: %s", errOrderProductNotFound, productID)})
+		return
+	}
+	if !product.IsPublished() {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: fmt.Sprintf("%s: %s", errProductNotForSale, productID)})
+		return
+	}
+	now := time.Now()
+	schedules, err := ch.PriceScheduleDB.FindActive([]string{productID}, now)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	price := entity.ResolvePrice(product, schedules[productID]).Price
+
+	userID := claimString(r, "sub")
+	cartDB := ch.cartDB(r)
+	item, err := cartDB.FindItem(userID, productID)
+	switch {
+	case err == nil:
+		item.Quantity += input.Quantity
+		item.AddedPrice = price
+		item.UpdatedAt = now
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		items, err := cartDB.FindItems(userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if len(items) >= maxOrderItems {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Message: errCartTooLarge.Error()})
+			return
+		}
+		item, err = entity.NewCartItem(userID, product.ID, price, input.Quantity)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := cartDB.SaveItem(item); err != nil {
+		fmt.Println("error to save cart item", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	ch.writeCart(w, r)
+}
+
+// Update Cart Item godoc
+// @Summary Change the quantity of a product in my cart
+// @Description Set the quantity of a product that is in the cart. Its added price is kept.
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param productID path string true "product ID" Format(uuid)
+// @Param request body dto.CartQuantityInput true "quantity request"
+// @Success 200 {object} entity.Cart
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cart/items/{productID} [put]
+// @Security ApiKeyAuth
+func (ch *CartHandler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "productID")
+	if _, err := entityPKG.ParseID(productID); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var input dto.CartQuantityInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if input.Quantity <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: entity.ErrInvalidQuantity.Error()})
+		return
+	}
+
+	cartDB := ch.cartDB(r)
+	item, err := cartDB.FindItem(claimString(r, "sub"), productID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	item.Quantity = input.Quantity
+	item.UpdatedAt = time.Now()
+	if err := cartDB.SaveItem(item); err != nil {
+		fmt.Println("error to save cart item", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	ch.writeCart(w, r)
+}
+
+// Delete Cart Item godoc
+// @Summary Remove a product from my cart
+// @Description Remove a product from the cart
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param productID path string true "product ID" Format(uuid)
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cart/items/{productID} [delete]
+// @Security ApiKeyAuth
+func (ch *CartHandler) DeleteCartItem(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "productID")
+	if _, err := entityPKG.ParseID(productID); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := ch.cartDB(r).DeleteItem(claimString(r, "sub"), productID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Checkout godoc
+// @Summary Check my cart out
+// @Description Price the cart at the current price of its products, save it as a quote and empty the cart, all in one transaction. Quotes never change afterwards. Every product must still exist and be published, and all must be priced in one currency.
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Success 201 {object} entity.Quote
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /cart/checkout [post]
+// @Security ApiKeyAuth
+func (ch *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	userID := claimString(r, "sub")
+	now := time.Now()
+	quote, err := ch.cartDB(r).Checkout(userID, func(items []*entity.CartItem, productDB database.ProductInterface, priceScheduleDB database.PriceScheduleInterface) (*entity.Quote, error) {
+		cart, err := priceCart(productDB, priceScheduleDB, items, now)
+		if err != nil {
+			return nil, err
+		}
+		return entity.NewQuote(userID, cart)
+	})
+	switch {
+	case errors.Is(err, entity.ErrCartIsEmpty):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	case errors.Is(err, entity.ErrCartUnavailable),
+		errors.Is(err, entity.ErrCartCurrencies):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	case errors.Is(err, database.ErrCartChanged):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	case err != nil:
+		fmt.Println("error to check cart out", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(quote)
+}
+
+// Get Quote godoc
+// @Summary Get a quote
+// @Description Get a quote of the user making the request. Admins get any quote of the organization.
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param id path string true "quote ID" Format(uuid)
+// @Success 200 {object} entity.Quote
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /cart/quotes/{id} [get]
+// @Security ApiKeyAuth
+func (ch *CartHandler) GetQuote(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	quote, err := ch.cartDB(r).FindQuote(id)
+	if err != nil || (quote.UserID != claimString(r, "sub") && !isAdmin(r)) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(quote)
+}
+
+// writeCart responds with the priced cart of the user making the request.
+func (ch *CartHandler) writeCart(w http.ResponseWriter, r *http.Request) {
+	items, err := ch.cartDB(r).FindItems(claimString(r, "sub"))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	cart, err := priceCart(ch.productDB(r), ch.PriceScheduleDB, items, time.Now())
+	if err != nil {
+		fmt.Println("error to price cart", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(cart)
+}
+
+// priceCart prices items at the effective price their products have at now.
+// Products in the trash keep their name but have no price. Neither do
+// products that are no longer published. Purging a product removes it from
+// every cart.
+func priceCart(productDB database.ProductInterface, priceScheduleDB database.PriceScheduleInterface, items []*entity.CartItem, now time.Time) (*entity.Cart, error) {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ProductID.String()
+	}
+	schedules, err := priceScheduleDB.FindActive(ids, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []entity.CartLine
+	for _, item := range items {
+		id := item.ProductID.String()
+		var line entity.CartLine
+		product, err := productDB.FindById(id)
+		switch {
+		case err == nil && !product.IsPublished():
+			line, err = entity.NewCartLine(item, product.Name, nil)
+		case err == nil:
+			price := entity.ResolvePrice(product, schedules[id]).Price
+			line, err = entity.NewCartLine(item, product.Name, &price)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			var name string
+			if deleted, err := productDB.FindDeletedById(id); err == nil {
+				name = deleted.Name
+			}
+			line, err = entity.NewCartLine(item, name, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return entity.NewCart(lines)
+}
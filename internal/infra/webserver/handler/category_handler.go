@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/go-chi/chi"
+	"gorm.io/gorm"
+)
+
+type CategoryHandler struct {
+	CategoryDB database.CategoryInterface
+}
+
+func NewCategoryHandler(categoryDB database.CategoryInterface) *CategoryHandler {
+	return &CategoryHandler{
+		CategoryDB: categoryDB,
+	}
+}
+
+func (ch *CategoryHandler) categoryDB(r *http.Request) database.CategoryInterface {
+	return ch.CategoryDB.ForTenant(claimString(r, "tid"))
+}
+
+// Create Category godoc
+// @Summary Create category
+// @Description Create a category, optionally below a parent category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param request body dto.CategoryInput true "category request"
+// @Success 201 {object} entity.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories [post]
+// @Security ApiKeyAuth
+func (ch *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var input dto.CategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	category, err := entity.NewCategory(input.Name, input.ParentID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := ch.categoryDB(r).Create(category); err != nil {
+		handleCategoryWriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(category)
+}
+
+// List Categories godoc
+// @Summary List categories
+// @Description List categories by name
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param parent_id query string false "only children of this category; send it empty for root categories"
+// @Success 200 {array} entity.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories [get]
+// @Security ApiKeyAuth
+func (ch *CategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	var parentID *string
+	if r.URL.Query().Has("parent_id") {
+		id := r.URL.Query().Get("parent_id")
+		if _, err := entityPKG.ParseID(id); id != "" && err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Message: fmt.Sprintf("invalid parent_id: %q", id)})
+			return
+		}
+		parentID = &id
+	}
+
+	categories, err := ch.categoryDB(r).FindAll(parentID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(categories)
+}
+
+// Get Category godoc
+// @Summary Get a category
+// @Description Get a category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "category ID" Format(uuid)
+// @Success 200 {object} entity.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /categories/{id} [get]
+// @Security ApiKeyAuth
+func (ch *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	category, err := ch.categoryDB(r).FindById(id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(category)
+}
+
+// Update Category godoc
+// @Summary Update a category
+// @Description Rename a category or move it below another parent
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "category ID" Format(uuid)
+// @Param request body dto.CategoryInput true "category request"
+// @Success 200 {object} entity.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories/{id} [put]
+// @Security ApiKeyAuth
+func (ch *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var input dto.CategoryInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	category, err := ch.categoryDB(r).FindById(id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	category.Name = input.Name
+	category.ParentID = input.ParentID
+	if err := category.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := ch.categoryDB(r).Update(category); err != nil {
+		handleCategoryWriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(category)
+}
+
+// Delete Category godoc
+// @Summary Delete a category
+// @Description Delete a category that has no subcategories and no products
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "category ID" Format(uuid)
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories/{id} [delete]
+// @Security ApiKeyAuth
+func (ch *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := ch.categoryDB(r).Delete(id); err != nil {
+		handleCategoryWriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Category Breadcrumb godoc
+// @Summary Category breadcrumb
+// @Description List the categories from the root down to this category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "category ID" Format(uuid)
+// @Success 200 {array} entity.Category
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /categories/{id}/breadcrumb [get]
+// @Security ApiKeyAuth
+func (ch *CategoryHandler) GetCategoryBreadcrumb(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	breadcrumb, err := ch.categoryDB(r).FindBreadcrumb(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(breadcrumb)
+}
+
+func handleCategoryWriteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, database.ErrCategoryNotFound):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	case errors.Is(err, database.ErrCategoryCycle),
+		errors.Is(err, database.ErrCategoryHasChildren),
+		errors.Is(err, database.ErrCategoryInUse):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	default:
+		fmt.Println("error to write category", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	errIfMatchRequired = errors.New("If-Match header is required, send the ETag returned by GET")
+	errInvalidIfMatch  = errors.New("invalid If-Match header")
+	errStaleVersion    = errors.New("product was modified, fetch it again and retry")
+)
+
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion returns the version sent in the If-Match header. "*" matches
+// whatever version is current, so current is returned in that case.
+func ifMatchVersion(r *http.Request, current int) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch value {
+	case "":
+		return 0, errIfMatchRequired
+	case "*":
+		return current, nil
+	}
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// checkIfMatch writes the matching error response and returns false when the
+// request does not carry a usable If-Match header for current.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, current int) (int, bool) {
+	version, err := ifMatchVersion(r, current)
+	switch {
+	case errors.Is(err, errIfMatchRequired):
+		w.WriteHeader(http.StatusPreconditionRequired)
+	case err != nil:
+		w.WriteHeader(http.StatusBadRequest)
+	case version != current:
+		err = errStaleVersion
+		w.WriteHeader(http.StatusPreconditionFailed)
+	default:
+		return version, true
+	}
+	json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	return 0, false
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+)
+
+type ExchangeRateHandler struct {
+	ExchangeRateDB database.ExchangeRateInterface
+}
+
+func NewExchangeRateHandler(exchangeRateDB database.ExchangeRateInterface) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		ExchangeRateDB: exchangeRateDB,
+	}
+}
+
+// Upload Exchange Rates godoc
+// @Summary Upload exchange rates
+// @Description Store a list of exchange rates. A rate for a pair and date that already exists is replaced. Admin only.
+// @Tags exchange rates
+// @Accept json
+// @Produce json
+// @Param request body []dto.ExchangeRateInput true "exchange rates"
+// @Success 201 {array} entity.ExchangeRate
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /exchange-rates [post]
+// @Security ApiKeyAuth
+func (eh *ExchangeRateHandler) UploadExchangeRates(w http.ResponseWriter, r *http.Request) {
+	var input []dto.ExchangeRateInput
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil || len(input) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: "body must be a non-empty list of rates"})
+		return
+	}
+
+	rates := make([]*entity.ExchangeRate, 0, len(input))
+	for i, rate := range input {
+		date, err := time.Parse(time.DateOnly, rate.Date)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Message: fmt.Sprintf("rate %d: date must be YYYY-MM-DD", i)})
+			return
+		}
+		exchangeRate, err := entity.NewExchangeRate(rate.From, rate.To, rate.Rate, date)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Message: fmt.Sprintf("rate %d: %s", i, err)})
+			return
+		}
+		rates = append(rates, exchangeRate)
+	}
+
+	err = eh.ExchangeRateDB.Save(rates)
+	if err != nil {
+		fmt.Println("error to save exchange rates", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rates)
+}
+
+// List Exchange Rates godoc
+// @Summary List exchange rates
+// @Description List uploaded exchange rates, newest first
+// @Tags exchange rates
+// @Accept json
+// @Produce json
+// @Param from query string false "source currency"
+// @Param to query string false "target currency"
+// @Success 200 {array} entity.ExchangeRate
+// @Failure 500 {object} ErrorResponse
+// @Router /exchange-rates [get]
+// @Security ApiKeyAuth
+func (eh *ExchangeRateHandler) GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := eh.ExchangeRateDB.FindAll(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rates)
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/jwtauth"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTestDB opens an in-memory database with models migrated.
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to file::memory: gets its own database.
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// serveAs serves the request through handler as if it carried a JWT with
+// claims, the way jwtauth.Verifier leaves it.
+func serveAs(handler http.Handler, r *http.Request, claims map[string]interface{}) *httptest.ResponseRecorder {
+	token, _, _ := jwtauth.New("HS256", []byte("secret"), nil).Encode(claims)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r.WithContext(jwtauth.NewContext(r.Context(), token, nil)))
+	return w
+}
@@ -0,0 +1,474 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/storage"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/FreitasGabriel/fullcycle-api/pkg/thumbnail"
+	"github.com/go-chi/chi"
+	"gorm.io/gorm"
+)
+
+// multipartOverhead is allowed on top of the image size for the multipart
+// boundaries and headers of an upload.
+const multipartOverhead = 64 << 10
+
+type ImageHandler struct {
+	ImageDB   database.ProductImageInterface
+	ProductDB database.ProductInterface
+	Storage   storage.BlobStorage
+	MaxSize   int64
+}
+
+func NewImageHandler(imageDB database.ProductImageInterface, productDB database.ProductInterface, blobStorage storage.BlobStorage, maxSize int64) *ImageHandler {
+	if maxSize < 1 {
+		maxSize = 5 << 20
+	}
+	return &ImageHandler{
+		ImageDB:   imageDB,
+		ProductDB: productDB,
+		Storage:   blobStorage,
+		MaxSize:   maxSize,
+	}
+}
+
+// Upload Image godoc
+// @Summary Upload product image
+// @Description Upload a JPEG, PNG or GIF image of a product as the multipart field image. Thumbnails are generated for it. Images can be at most 8000 pixels wide and high and 25 megapixels. The first image of a product becomes its primary image. Only the owner of the product or an admin can do it.
+// @Tags images
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param image formData file true "image file"
+// @Success 201 {object} dto.ProductImageOutput
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/images [post]
+// @Security ApiKeyAuth
+func (ih *ImageHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
+	productID, err := entityPKG.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !checkProductOwnerByID(w, r, ih.ProductDB, productID.String()) {
+		return
+	}
+
+	content, err := ih.readUpload(w, r)
+	if err != nil {
+		return
+	}
+
+	contentType := http.DetectContentType(content)
+	if !entity.IsImageContentType(contentType) {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: entity.ErrUnsupportedImageType.Error()})
+		return
+	}
+
+	// Decoding allocates for the declared dimensions, so they are checked
+	// from the header first.
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: "image could not be decoded"})
+		return
+	}
+	if err := entity.CheckImageDimensions(config.Width, config.Height); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: "image could not be decoded"})
+		return
+	}
+
+	bounds := img.Bounds()
+	productImage, err := entity.NewProductImage(productID, contentType, int64(len(content)), bounds.Dx(), bounds.Dy())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := ih.storeFiles(productImage, content, img); err != nil {
+		fmt.Println("error to store image", err)
+		ih.deleteFiles(productImage)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := ih.ImageDB.Create(productImage); err != nil {
+		ih.deleteFiles(productImage)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Println("error to create image", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(imageOutput(productImage))
+}
+
+// readUpload returns the content of the image field of a multipart upload,
+// writing an error response and returning an error when there is none or it
+// is larger than MaxSize.
+func (ih *ImageHandler) readUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, ih.MaxSize+multipartOverhead)
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ih.writeTooLarge(w)
+			return nil, err
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: "multipart field image is required"})
+		return nil, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, ih.MaxSize+1))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, err
+	}
+	if int64(len(content)) > ih.MaxSize {
+		ih.writeTooLarge(w)
+		return nil, errors.New("image too large")
+	}
+	return content, nil
+}
+
+func (ih *ImageHandler) writeTooLarge(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	json.NewEncoder(w).Encode(ErrorResponse{Message: fmt.Sprintf("image must not be larger than %d bytes", ih.MaxSize)})
+}
+
+func (ih *ImageHandler) storeFiles(productImage *entity.ProductImage, content []byte, img image.Image) error {
+	if err := ih.Storage.Put(productImage.OriginalKey(), bytes.NewReader(content)); err != nil {
+		return err
+	}
+	for size, side := range entity.ThumbnailSizes {
+		var buf bytes.Buffer
+		var err error
+		if productImage.ThumbnailContentType() == "image/jpeg" {
+			err = jpeg.Encode(&buf, thumbnail.Fit(img, side), nil)
+		} else {
+			err = png.Encode(&buf, thumbnail.Fit(img, side))
+		}
+		if err != nil {
+			return err
+		}
+		key, _ := productImage.ThumbnailKey(size)
+		if err := ih.Storage.Put(key, &buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteFiles removes the files of an image, logging failures, since the
+// image is gone or was never saved by the time it runs.
+func (ih *ImageHandler) deleteFiles(productImage *entity.ProductImage) {
+	keys := []string{productImage.OriginalKey()}
+	for size := range entity.ThumbnailSizes {
+		key, _ := productImage.ThumbnailKey(size)
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if err := ih.Storage.Delete(key); err != nil {
+			fmt.Println("error to delete image file", key, err)
+		}
+	}
+}
+
+// List Images godoc
+// @Summary List product images
+// @Description List the images of a product in display order
+// @Tags images
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Success 200 {array} dto.ProductImageOutput
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/images [get]
+// @Security ApiKeyAuth
+func (ih *ImageHandler) GetImages(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	images, err := ih.ImageDB.FindByProduct(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	output := make([]dto.ProductImageOutput, 0, len(images))
+	for _, productImage := range images {
+		output = append(output, imageOutput(productImage))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(output)
+}
+
+// Get Image File godoc
+// @Summary Download a product image
+// @Description Download the original file of a product image
+// @Tags images
+// @Produce image/jpeg,image/png,image/gif
+// @Param id path string true "product ID" Format(uuid)
+// @Param imageID path string true "image ID" Format(uuid)
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/images/{imageID}/file [get]
+// @Security ApiKeyAuth
+func (ih *ImageHandler) GetImageFile(w http.ResponseWriter, r *http.Request) {
+	productImage, ok := ih.findImage(w, r)
+	if !ok {
+		return
+	}
+	ih.serveFile(w, productImage.OriginalKey(), productImage.ContentType)
+}
+
+// Get Image Thumbnail godoc
+// @Summary Download a product image thumbnail
+// @Description Download a resized copy of a product image
+// @Tags images
+// @Produce image/jpeg,image/png
+// @Param id path string true "product ID" Format(uuid)
+// @Param imageID path string true "image ID" Format(uuid)
+// @Param size path string true "thumbnail size" Enums(small, medium)
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/images/{imageID}/thumbnails/{size} [get]
+// @Security ApiKeyAuth
+func (ih *ImageHandler) GetImageThumbnail(w http.ResponseWriter, r *http.Request) {
+	productImage, ok := ih.findImage(w, r)
+	if !ok {
+		return
+	}
+	key, err := productImage.ThumbnailKey(chi.URLParam(r, "size"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+	ih.serveFile(w, key, productImage.ThumbnailContentType())
+}
+
+func (ih *ImageHandler) serveFile(w http.ResponseWriter, key, contentType string) {
+	file, err := ih.Storage.Get(key)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		fmt.Println("error to read image file", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, file)
+}
+
+// Set Primary Image godoc
+// @Summary Set the primary product image
+// @Description Make an image the primary image of its product. Only the owner of the product or an admin can do it.
+// @Tags images
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param imageID path string true "image ID" Format(uuid)
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/images/{imageID}/primary [post]
+// @Security ApiKeyAuth
+func (ih *ImageHandler) SetPrimaryImage(w http.ResponseWriter, r *http.Request) {
+	id, imageID, ok := imageParams(w, r)
+	if !ok {
+		return
+	}
+	if !checkProductOwnerByID(w, r, ih.ProductDB, id) {
+		return
+	}
+
+	err := ih.ImageDB.SetPrimary(id, imageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Reorder Images godoc
+// @Summary Reorder product images
+// @Description Set the display order of the images of a product. The list must contain every image id exactly once. Only the owner of the product or an admin can do it.
+// @Tags images
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param request body dto.ImageOrderInput true "image ids in display order"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/images/order [put]
+// @Security ApiKeyAuth
+func (ih *ImageHandler) ReorderImages(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !checkProductOwnerByID(w, r, ih.ProductDB, id) {
+		return
+	}
+
+	var input dto.ImageOrderInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := ih.ImageDB.Reorder(id, input.IDs)
+	if errors.Is(err, database.ErrInvalidImageOrder) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete Image godoc
+// @Summary Delete a product image
+// @Description Delete a product image and its files. When it was the primary image the next image takes its place. Only the owner of the product or an admin can do it.
+// @Tags images
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param imageID path string true "image ID" Format(uuid)
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/images/{imageID} [delete]
+// @Security ApiKeyAuth
+func (ih *ImageHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	productImage, ok := ih.findImage(w, r)
+	if !ok {
+		return
+	}
+	if !checkProductOwnerByID(w, r, ih.ProductDB, productImage.ProductID.String()) {
+		return
+	}
+
+	err := ih.ImageDB.Delete(productImage.ProductID.String(), productImage.ID.String())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	ih.deleteFiles(productImage)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ih *ImageHandler) findImage(w http.ResponseWriter, r *http.Request) (*entity.ProductImage, bool) {
+	id, imageID, ok := imageParams(w, r)
+	if !ok {
+		return nil, false
+	}
+	productImage, err := ih.ImageDB.FindById(id, imageID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return nil, false
+	}
+	return productImage, true
+}
+
+// imageParams reads the product and image ids from the path, writing a 400
+// response and returning false when either is not a valid id.
+func imageParams(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	id := chi.URLParam(r, "id")
+	imageID := chi.URLParam(r, "imageID")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return "", "", false
+	}
+	if _, err := entityPKG.ParseID(imageID); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return "", "", false
+	}
+	return id, imageID, true
+}
+
+func imageOutput(productImage *entity.ProductImage) dto.ProductImageOutput {
+	base := "/products/" + productImage.ProductID.String() + "/images/" + productImage.ID.String()
+	thumbnails := map[string]string{}
+	for size := range entity.ThumbnailSizes {
+		thumbnails[size] = base + "/thumbnails/" + size
+	}
+	return dto.ProductImageOutput{
+		ProductImage: productImage,
+		URL:          base + "/file",
+		Thumbnails:   thumbnails,
+	}
+}
@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/go-chi/chi"
+	"gorm.io/gorm"
+)
+
+// maxImportSize is the largest import file accepted, in bytes.
+const maxImportSize = 32 << 20
+
+// importProgressEvery is how many rows an import job processes between
+// saves of its report, so polling clients see it progress.
+const importProgressEvery = 100
+
+var errImportProductNotFound = errors.New("product not found")
+
+type ImportHandler struct {
+	ProductDB   database.ProductInterface
+	CategoryDB  database.CategoryInterface
+	ImportJobDB database.ImportJobInterface
+	SyncLimit   int
+}
+
+func NewImportHandler(productDB database.ProductInterface, categoryDB database.CategoryInterface, importJobDB database.ImportJobInterface, syncLimit int) *ImportHandler {
+	if syncLimit < 1 {
+		syncLimit = 100
+	}
+	return &ImportHandler{
+		ProductDB:   productDB,
+		CategoryDB:  categoryDB,
+		ImportJobDB: importJobDB,
+		SyncLimit:   syncLimit,
+	}
+}
+
+// Import Products godoc
+// @Summary Import products
+// @Description Create and update products in bulk from a CSV or NDJSON file sent as the request body. CSV files need a header naming the columns name, price and currency, and optionally id, category_id and tags separated by "|". NDJSON lines use the fields of dto.ImportProductInput. Rows with an id update that product, which only its owner or an admin can do, and the others create one owned by the importing user. Every row is validated on its own and the report lists, by 1-based data row, whether it was created, updated or rejected and why. Files with up to the configured number of rows are imported before responding; larger files are imported in the background and answered with 202 and the job to poll.
+// @Tags products
+// @Accept text/csv,application/x-ndjson
+// @Produce json
+// @Param format query string false "file format, taken from Content-Type when omitted" Enums(csv, ndjson)
+// @Param dry_run query bool false "validate every row and report the outcome without writing anything"
+// @Success 200 {object} entity.ImportJob
+// @Success 202 {object} entity.ImportJob
+// @Header 202 {string} Location "URL of the import job"
+// @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/import [post]
+// @Security ApiKeyAuth
+func (ih *ImportHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	format, err := importFormat(r.URL.Query().Get("format"), r.Header.Get("Content-Type"))
+	if err != nil {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Message: "dry_run must be true or false"})
+			return
+		}
+	}
+
+	rows, err := readImportRows(format, http.MaxBytesReader(w, r.Body, maxImportSize))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: fmt.Sprintf("file must not be larger than %d bytes", maxImportSize)})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+	if len(rows) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: "file has no rows"})
+		return
+	}
+
+	rq := requesterOf(r)
+	job, err := entity.NewImportJob(rq.userID, format, dryRun, len(rows))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+	if err := ih.ImportJobDB.Create(job); err != nil {
+		fmt.Println("error to create import job", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	productDB := ih.ProductDB.ForTenant(rq.tenantID).WithActor(rq.userID)
+	if len(rows) <= ih.SyncLimit {
+		ih.run(productDB, rq, job, rows)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(job)
+		return
+	}
+
+	// The response is written before the job starts, which owns job from
+	// then on.
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/products/import/"+job.ID.String())
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+	go ih.run(productDB, rq, job, rows)
+}
+
+// Get Import Job godoc
+// @Summary Get a product import
+// @Description Get the status and report of a product import. Users only see their own imports; admins see all of them.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param jobID path string true "import job ID" Format(uuid)
+// @Success 200 {object} entity.ImportJob
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/import/{jobID} [get]
+// @Security ApiKeyAuth
+func (ih *ImportHandler) GetImportJob(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "jobID")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	job, err := ih.ImportJobDB.FindById(id)
+	if err != nil || (job.UserID != claimString(r, "sub") && !isAdmin(r)) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(job)
+}
+
+// run imports rows one by one, each in its own transaction, so a rejected
+// row never undoes the others. An unexpected error fails the job and leaves
+// the remaining rows unprocessed.
+func (ih *ImportHandler) run(productDB database.ProductInterface, rq requester, job *entity.ImportJob, rows []importRow) {
+	job.Start()
+	ih.saveJob(job)
+
+	for i, row := range rows {
+		result, err := ih.importProduct(productDB, rq, row, job.DryRun)
+		if err != nil {
+			fmt.Println("error to import product", err)
+			job.Fail(fmt.Sprintf("row %d: %s", row.number, err), time.Now())
+			ih.saveJob(job)
+			return
+		}
+		job.Record(result)
+		if (i+1)%importProgressEvery == 0 {
+			ih.saveJob(job)
+		}
+	}
+
+	job.Finish(time.Now())
+	ih.saveJob(job)
+}
+
+func (ih *ImportHandler) saveJob(job *entity.ImportJob) {
+	if err := ih.ImportJobDB.Update(job); err != nil {
+		fmt.Println("error to save import job", err)
+	}
+}
+
+// importProduct validates a row through entity.NewProduct and creates or
+// updates its product on behalf of rq unless dryRun is set. Problems with
+// the row itself reject it; only unexpected errors are returned.
+func (ih *ImportHandler) importProduct(productDB database.ProductInterface, rq requester, row importRow, dryRun bool) (entity.ImportRowResult, error) {
+	result := entity.ImportRowResult{Row: row.number}
+	reject := func(err error) (entity.ImportRowResult, error) {
+		result.Status = entity.ImportRowRejected
+		result.Error = err.Error()
+		return result, nil
+	}
+	if row.err != nil {
+		return reject(row.err)
+	}
+
+	input := row.input
+	product, err := entity.NewProduct(input.Name, input.Price)
+	if err != nil {
+		return reject(err)
+	}
+	product.CategoryID = input.CategoryID
+	product.OwnerID = rq.userID
+	product.SetTags(input.Tags)
+	if err := product.Validate(); err != nil {
+		return reject(err)
+	}
+	if input.CategoryID != nil {
+		_, err := ih.CategoryDB.ForTenant(rq.tenantID).FindById(input.CategoryID.String())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return reject(database.ErrCategoryNotFound)
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if input.ID == nil {
+		result.Status = entity.ImportRowCreated
+		if dryRun {
+			return result, nil
+		}
+		if err := productDB.Create(product); err != nil {
+			return ih.importWriteError(result, err)
+		}
+		result.ProductID = &product.ID
+		return result, nil
+	}
+
+	existing, err := productDB.FindById(input.ID.String())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return reject(errImportProductNotFound)
+	}
+	if err != nil {
+		return result, err
+	}
+	if !rq.canModify(existing) {
+		return reject(errNotProductOwner)
+	}
+	existing.Name = product.Name
+	existing.Price = product.Price
+	existing.CategoryID = product.CategoryID
+	existing.Tags = product.Tags
+
+	result.Status = entity.ImportRowUpdated
+	result.ProductID = &existing.ID
+	if dryRun {
+		return result, nil
+	}
+	if err := productDB.Update(existing); err != nil {
+		return ih.importWriteError(result, err)
+	}
+	return result, nil
+}
+
+// importWriteError rejects rows that lost a race with another change, such
+// as their category being deleted, and returns any other error.
+func (ih *ImportHandler) importWriteError(result entity.ImportRowResult, err error) (entity.ImportRowResult, error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errImportProductNotFound
+	}
+	switch {
+	case errors.Is(err, database.ErrCategoryNotFound),
+		errors.Is(err, database.ErrVersionConflict),
+		errors.Is(err, errImportProductNotFound):
+		result.Status = entity.ImportRowRejected
+		result.ProductID = nil
+		result.Error = err.Error()
+		return result, nil
+	}
+	return result, err
+}
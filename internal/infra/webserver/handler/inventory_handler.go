@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/go-chi/chi"
+	"gorm.io/gorm"
+)
+
+// maxReservationTTL caps the ttl_seconds a client may ask for.
+const maxReservationTTL = 24 * time.Hour
+
+var errNotReservationHolder = errors.New("only the holder of the reservation, the owner of the product or an admin can release it")
+
+type InventoryHandler struct {
+	StockDB        database.StockInterface
+	ProductDB      database.ProductInterface
+	ReservationTTL time.Duration
+}
+
+func NewInventoryHandler(stockDB database.StockInterface, productDB database.ProductInterface, reservationTTLSeconds int) *InventoryHandler {
+	if reservationTTLSeconds < 1 {
+		reservationTTLSeconds = 900
+	}
+	return &InventoryHandler{
+		StockDB:        stockDB,
+		ProductDB:      productDB,
+		ReservationTTL: time.Duration(reservationTTLSeconds) * time.Second,
+	}
+}
+
+// Get Stock godoc
+// @Summary Get product stock
+// @Description Get the units of a product on hand, reserved and available
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Success 200 {object} dto.StockLevelOutput
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/stock [get]
+// @Security ApiKeyAuth
+func (ih *InventoryHandler) GetStock(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	level, err := ih.StockDB.FindLevel(id)
+	if err != nil {
+		handleStockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stockLevelOutput(level))
+}
+
+// Adjust Stock godoc
+// @Summary Adjust product stock
+// @Description Record units received or sold, or correct the stock by a signed quantity. Only the owner of the product or an admin can do it.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param request body dto.StockAdjustmentInput true "stock adjustment"
+// @Success 201 {object} dto.StockLevelOutput
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/stock/adjustments [post]
+// @Security ApiKeyAuth
+func (ih *InventoryHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	productID, err := entityPKG.ParseID(id)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !checkProductOwnerByID(w, r, ih.ProductDB, id) {
+		return
+	}
+
+	var input dto.StockAdjustmentInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	movement, err := entity.NewStockMovement(productID, input.Kind, input.Quantity, input.Reason, claimString(r, "sub"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := ih.StockDB.Adjust(movement); err != nil {
+		handleStockError(w, err)
+		return
+	}
+
+	level, err := ih.StockDB.FindLevel(id)
+	if err != nil {
+		handleStockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(stockLevelOutput(level))
+}
+
+// List Stock Adjustments godoc
+// @Summary List stock adjustments
+// @Description List the stock ledger of a product, oldest first
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Success 200 {array} entity.StockMovement
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/stock/adjustments [get]
+// @Security ApiKeyAuth
+func (ih *InventoryHandler) GetStockAdjustments(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	movements, err := ih.StockDB.FindMovements(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(movements)
+}
+
+// Reserve Stock godoc
+// @Summary Reserve product stock
+// @Description Hold units of a product until the reservation is released or expires
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param request body dto.ReservationInput true "reservation request"
+// @Success 201 {object} entity.Reservation
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/reservations [post]
+// @Security ApiKeyAuth
+func (ih *InventoryHandler) CreateReservation(w http.ResponseWriter, r *http.Request) {
+	productID, err := entityPKG.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var input dto.ReservationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ttl := ih.ReservationTTL
+	if input.TTLSeconds != 0 {
+		ttl = time.Duration(input.TTLSeconds) * time.Second
+	}
+	if ttl > maxReservationTTL {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: fmt.Sprintf("ttl_seconds must not exceed %d", int(maxReservationTTL.Seconds()))})
+		return
+	}
+
+	reservation, err := entity.NewReservation(productID, input.Quantity, claimString(r, "sub"), ttl)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := ih.StockDB.Reserve(reservation); err != nil {
+		handleStockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// Release Reservation godoc
+// @Summary Release a reservation
+// @Description Give the units held by a reservation back. Only the user holding the reservation, the owner of the product or an admin can release it.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param reservationID path string true "reservation ID" Format(uuid)
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/reservations/{reservationID} [delete]
+// @Security ApiKeyAuth
+func (ih *InventoryHandler) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	reservationID := chi.URLParam(r, "reservationID")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if _, err := entityPKG.ParseID(reservationID); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := ih.StockDB.FindReservation(id, reservationID)
+	if err != nil {
+		handleStockError(w, err)
+		return
+	}
+	if reservation.UserID != claimString(r, "sub") {
+		product, err := findTenantProduct(r, ih.ProductDB, id)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !requesterOf(r).canModify(product) {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Message: errNotReservationHolder.Error()})
+			return
+		}
+	}
+
+	if err := ih.StockDB.Release(id, reservationID); err != nil {
+		handleStockError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func stockLevelOutput(level *entity.StockLevel) dto.StockLevelOutput {
+	return dto.StockLevelOutput{
+		ProductID: level.ProductID.String(),
+		OnHand:    level.OnHand,
+		Reserved:  level.Reserved,
+		Available: level.Available(),
+	}
+}
+
+func handleStockError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, database.ErrReservationNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	case errors.Is(err, database.ErrInsufficientStock),
+		errors.Is(err, database.ErrReservationNotActive):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	default:
+		fmt.Println("error to update stock", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReleaseReservationOfAnotherUser(t *testing.T) {
+	db := newTestDB(t, &entity.Product{}, &entity.ProductHistory{}, &entity.SlugRedirect{},
+		&entity.StockLevel{}, &entity.StockMovement{}, &entity.Reservation{})
+	productDB := database.NewProduct(db)
+	stockDB := database.NewStock(db)
+
+	product, _ := entity.NewProduct("Shirt", entityPKG.Money{Amount: 1000, Currency: "BRL"})
+	product.OwnerID = "owner"
+	assert.NoError(t, productDB.ForTenant("tenant-1").Create(product))
+	movement, _ := entity.NewStockMovement(product.ID, entity.StockReceived, 5, "", "owner")
+	assert.NoError(t, stockDB.Adjust(movement))
+	reservation, _ := entity.NewReservation(product.ID, 2, "holder", time.Hour)
+	assert.NoError(t, stockDB.Reserve(reservation))
+
+	router := chi.NewRouter()
+	router.Delete("/products/{id}/reservations/{reservationID}", NewInventoryHandler(stockDB, productDB, 0).ReleaseReservation)
+	release := func(sub string) int {
+		r := httptest.NewRequest(http.MethodDelete, "/products/"+product.ID.String()+"/reservations/"+reservation.ID.String(), nil)
+		return serveAs(router, r, map[string]interface{}{"sub": sub, "tid": "tenant-1", "role": entity.RoleUser}).Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, release("someone-else"))
+	level, _ := stockDB.FindLevel(product.ID.String())
+	assert.Equal(t, 2, level.Reserved)
+
+	assert.Equal(t, http.StatusNoContent, release("holder"))
+	level, _ = stockDB.FindLevel(product.ID.String())
+	assert.Equal(t, 0, level.Reserved)
+}
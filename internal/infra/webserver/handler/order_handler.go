@@ -0,0 +1,348 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/go-chi/chi"
+	"gorm.io/gorm"
+)
+
+// maxOrderItems is the largest number of distinct products an order holds.
+const maxOrderItems = 100
+
+var (
+	errOrderProductNotFound = errors.New("product not found")
+	errProductNotForSale    = errors.New("product is not published")
+	errOrderTooLarge        = fmt.Errorf("an order holds at most %d products", maxOrderItems)
+	errOrderStatusForAdmins = errors.New("only admins can move an order to that status")
+)
+
+// customerOrderStatuses are the statuses users can move their own orders
+// to. Payment, shipping, delivering and refunding are left to admins.
+var customerOrderStatuses = map[string]bool{
+	entity.OrderCancelled: true,
+}
+
+type OrderHandler struct {
+	OrderDB         database.OrderInterface
+	ProductDB       database.ProductInterface
+	PriceScheduleDB database.PriceScheduleInterface
+	MaxPageSize     int
+}
+
+func NewOrderHandler(orderDB database.OrderInterface, productDB database.ProductInterface, priceScheduleDB database.PriceScheduleInterface, maxPageSize int) *OrderHandler {
+	if maxPageSize < 1 {
+		maxPageSize = 100
+	}
+	return &OrderHandler{
+		OrderDB:         orderDB,
+		ProductDB:       productDB,
+		PriceScheduleDB: priceScheduleDB,
+		MaxPageSize:     maxPageSize,
+	}
+}
+
+// orderDB returns the repository bound to the organization of the user
+// making the request.
+func (oh *OrderHandler) orderDB(r *http.Request) database.OrderInterface {
+	return oh.OrderDB.ForTenant(claimString(r, "tid"))
+}
+
+// Create Order godoc
+// @Summary Place an order
+// @Description Place a pending order for the products of the request. Each item keeps the name and effective price its product has at that moment, so later product changes leave the order untouched. All products must be published and priced in one currency.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateOrderInput true "order request"
+// @Success 201 {object} entity.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders [post]
+// @Security ApiKeyAuth
+func (oh *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	var input dto.CreateOrderInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rq := requesterOf(r)
+	order, err := buildOrder(oh.ProductDB.ForTenant(rq.tenantID), oh.PriceScheduleDB, rq.userID, input.Items, time.Now())
+	if err != nil {
+		writeOrderError(w, err)
+		return
+	}
+
+	if err := oh.orderDB(r).Create(order); err != nil {
+		fmt.Println("error to create order", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+// buildOrder prices lines at now and places a pending order for userID with
+// them. Lines for the same product are merged, in the order they first
+// appear.
+func buildOrder(productDB database.ProductInterface, priceScheduleDB database.PriceScheduleInterface, userID string, lines []dto.OrderItemInput, now time.Time) (*entity.Order, error) {
+	var ids []string
+	quantities := map[string]int{}
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, entity.ErrInvalidQuantity
+		}
+		id := line.ProductID.String()
+		if _, ok := quantities[id]; !ok {
+			ids = append(ids, id)
+		}
+		quantities[id] += line.Quantity
+	}
+	if len(ids) > maxOrderItems {
+		return nil, errOrderTooLarge
+	}
+
+	schedules, err := priceScheduleDB.FindActive(ids, now)
+	if err != nil {
+		return nil, err
+	}
+	var items []*entity.OrderItem
+	for _, id := range ids {
+		product, err := productDB.FindById(id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", errOrderProductNotFound, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !product.IsPublished() {
+			return nil, fmt.Errorf("%w: %s", errProductNotForSale, id)
+		}
+		price := entity.ResolvePrice(product, schedules[id]).Price
+		item, err := entity.NewOrderItem(product.ID, product.Name, price, quantities[id])
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return entity.NewOrder(userID, items)
+}
+
+// writeOrderError maps errors from placing an order.
+func writeOrderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errOrderProductNotFound),
+		errors.Is(err, errProductNotForSale),
+		errors.Is(err, entity.ErrOrderCurrencies),
+		errors.Is(err, entityPKG.ErrAmountOverflow):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	case errors.Is(err, entity.ErrInvalidQuantity),
+		errors.Is(err, entity.ErrOrderHasNoItems),
+		errors.Is(err, errOrderTooLarge):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	default:
+		fmt.Println("error to place order", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// List Orders godoc
+// @Summary List my orders
+// @Description List the orders of the user making the request, most recent first
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param page query string false "page number"
+// @Param limit query string false "limit"
+// @Param status query string false "only orders in this status" Enums(pending, paid, shipped, delivered, cancelled, refunded)
+// @Success 200 {object} dto.OrderListOutput
+// @Header 200 {integer} X-Total-Count "total number of matching orders"
+// @Header 200 {string} Link "RFC 8288 pagination links"
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders [get]
+// @Security ApiKeyAuth
+func (oh *OrderHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
+	oh.listOrders(w, r, database.OrderFilter{UserID: claimString(r, "sub")})
+}
+
+// List All Orders godoc
+// @Summary List all orders
+// @Description List the orders of every user of the organization, most recent first. Admin only.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param page query string false "page number"
+// @Param limit query string false "limit"
+// @Param status query string false "only orders in this status" Enums(pending, paid, shipped, delivered, cancelled, refunded)
+// @Param user_id query string false "only orders of this user" Format(uuid)
+// @Success 200 {object} dto.OrderListOutput
+// @Header 200 {integer} X-Total-Count "total number of matching orders"
+// @Header 200 {string} Link "RFC 8288 pagination links"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/all [get]
+// @Security ApiKeyAuth
+func (oh *OrderHandler) GetAllOrders(w http.ResponseWriter, r *http.Request) {
+	oh.listOrders(w, r, database.OrderFilter{UserID: r.URL.Query().Get("user_id")})
+}
+
+func (oh *OrderHandler) listOrders(w http.ResponseWriter, r *http.Request, filter database.OrderFilter) {
+	filter.Status = r.URL.Query().Get("status")
+	if filter.Status != "" && !entity.IsOrderStatus(filter.Status) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: entity.ErrInvalidOrderStatus.Error()})
+		return
+	}
+
+	pageInt, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || pageInt < 1 {
+		pageInt = 1
+	}
+	limitInt, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limitInt < 1 {
+		limitInt = defaultPageSize
+	}
+	limitInt = min(limitInt, oh.MaxPageSize)
+
+	total, err := oh.orderDB(r).Count(filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	orders, err := oh.orderDB(r).FindAll(pageInt, limitInt, filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	output := dto.OrderListOutput{
+		Items:   orders,
+		Page:    pageInt,
+		Limit:   limitInt,
+		Total:   total,
+		HasNext: int64(pageInt*limitInt) < total,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	w.Header().Set("Link", pageLinks(r.URL, pageInt, limitInt, total))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(output)
+}
+
+// Get Order godoc
+// @Summary Get an order
+// @Description Get an order of the user making the request. Admins get any order of the organization.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "order ID" Format(uuid)
+// @Success 200 {object} entity.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /orders/{id} [get]
+// @Security ApiKeyAuth
+func (oh *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	order, ok := oh.findOrder(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(order)
+}
+
+// Update Order Status godoc
+// @Summary Move an order to another status
+// @Description Move an order along pending → paid → shipped → delivered, or to cancelled from pending or to refunded once paid. Other moves are rejected with 409. Users can cancel their own orders; the other moves are for admins.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "order ID" Format(uuid)
+// @Param request body dto.OrderStatusInput true "status request"
+// @Success 200 {object} entity.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /orders/{id}/status [put]
+// @Security ApiKeyAuth
+func (oh *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	var input dto.OrderStatusInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	order, ok := oh.findOrder(w, r)
+	if !ok {
+		return
+	}
+	if !isAdmin(r) && !customerOrderStatuses[input.Status] {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: errOrderStatusForAdmins.Error()})
+		return
+	}
+
+	from := order.Status
+	err := order.Transition(input.Status, time.Now())
+	if errors.Is(err, entity.ErrInvalidOrderStatus) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+	if err == nil {
+		err = oh.orderDB(r).UpdateStatus(order, from)
+	}
+	switch {
+	case errors.Is(err, entity.ErrInvalidOrderTransition),
+		errors.Is(err, database.ErrOrderStatusConflict):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	case err != nil:
+		fmt.Println("error to update order status", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(order)
+}
+
+// findOrder loads the order of the request, writing a 404 response and
+// returning false when it does not exist or belongs to another user and the
+// requester is not an admin.
+func (oh *OrderHandler) findOrder(w http.ResponseWriter, r *http.Request) (*entity.Order, bool) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+	order, err := oh.orderDB(r).FindById(id)
+	if err != nil || (order.UserID != claimString(r, "sub") && !isAdmin(r)) {
+		w.WriteHeader(http.StatusNotFound)
+		return nil, false
+	}
+	return order, true
+}
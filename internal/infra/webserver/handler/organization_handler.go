@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/go-chi/chi"
+	"gorm.io/gorm"
+)
+
+type OrganizationHandler struct {
+	OrganizationDB database.OrganizationInterface
+	UserDB         database.UserInterface
+}
+
+func NewOrganizationHandler(organizationDB database.OrganizationInterface, userDB database.UserInterface) *OrganizationHandler {
+	return &OrganizationHandler{
+		OrganizationDB: organizationDB,
+		UserDB:         userDB,
+	}
+}
+
+// Create Organization godoc
+// @Summary Create organization
+// @Description Create an organization, with the admin making the request as its first member. Admin only.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param request body dto.OrganizationInput true "organization request"
+// @Success 201 {object} entity.Organization
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /organizations [post]
+// @Security ApiKeyAuth
+func (oh *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	var input dto.OrganizationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ownerID, err := entityPKG.ParseID(claimString(r, "sub"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: "token has no valid user"})
+		return
+	}
+
+	organization, err := entity.NewOrganization(input.Name)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := oh.OrganizationDB.Create(organization, ownerID); err != nil {
+		fmt.Println("error to create organization", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(organization)
+}
+
+// List Organizations godoc
+// @Summary List my organizations
+// @Description List the organizations the user making the request is a member of, by name
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Success 200 {array} entity.Organization
+// @Failure 500 {object} ErrorResponse
+// @Router /organizations [get]
+// @Security ApiKeyAuth
+func (oh *OrganizationHandler) GetOrganizations(w http.ResponseWriter, r *http.Request) {
+	organizations, err := oh.OrganizationDB.FindByUser(claimString(r, "sub"))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(organizations)
+}
+
+// Add Member godoc
+// @Summary Add organization member
+// @Description Make a user, found by email, a member of an organization, so they can sign in to it. Admin only.
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "organization ID" Format(uuid)
+// @Param request body dto.MemberInput true "member request"
+// @Success 201 {object} entity.Membership
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /organizations/{id}/members [post]
+// @Security ApiKeyAuth
+func (oh *OrganizationHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	organizationID, err := entityPKG.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var input dto.MemberInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	user, err := oh.UserDB.FindByEmail(input.Email)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: "user not found"})
+		return
+	}
+
+	membership := entity.NewMembership(user.ID, organizationID)
+	err = oh.OrganizationDB.AddMember(membership)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: "organization not found"})
+		return
+	case errors.Is(err, database.ErrAlreadyMember):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	case err != nil:
+		fmt.Println("error to add organization member", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(membership)
+}
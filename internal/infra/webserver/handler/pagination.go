@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// pageLinks builds an RFC 8288 Link header value with the first, prev, next
+// and last pages of an offset listing. The request query is kept so the
+// links carry the same filters.
+func pageLinks(u *url.URL, page, limit int, total int64) string {
+	lastPage := int((total + int64(limit) - 1) / int64(limit))
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	links := []string{pageLink(u, 1, limit, "first")}
+	if page > 1 {
+		links = append(links, pageLink(u, min(page-1, lastPage), limit, "prev"))
+	}
+	if page < lastPage {
+		links = append(links, pageLink(u, page+1, limit, "next"))
+	}
+	links = append(links, pageLink(u, lastPage, limit, "last"))
+	return strings.Join(links, ", ")
+}
+
+func pageLink(u *url.URL, page, limit int, rel string) string {
+	query := u.Query()
+	query.Set("page", strconv.Itoa(page))
+	query.Set("limit", strconv.Itoa(limit))
+	return link(u, query, rel)
+}
+
+func cursorLink(u *url.URL, cursor string) string {
+	query := u.Query()
+	query.Set("cursor", cursor)
+	return link(u, query, "next")
+}
+
+func link(u *url.URL, query url.Values, rel string) string {
+	target := url.URL{Path: u.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf("<%s>; rel=\"%s\"", target.String(), rel)
+}
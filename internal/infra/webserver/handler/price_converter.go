@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"gorm.io/gorm"
+)
+
+var errRateNotFound = errors.New("no exchange rate available")
+
+// priceConverter resolves the effective prices of products when the request
+// is made and converts them into one currency, looking each exchange rate up
+// only once per request.
+type priceConverter struct {
+	exchangeRateDB  database.ExchangeRateInterface
+	priceScheduleDB database.PriceScheduleInterface
+	currency        string
+	at              time.Time
+	rates           map[string]*entity.ExchangeRate
+}
+
+func newPriceConverter(exchangeRateDB database.ExchangeRateInterface, priceScheduleDB database.PriceScheduleInterface, currency string) (*priceConverter, error) {
+	if currency != "" && !entityPKG.IsCurrency(currency) {
+		return nil, fmt.Errorf("invalid currency: %q", currency)
+	}
+	return &priceConverter{
+		exchangeRateDB:  exchangeRateDB,
+		priceScheduleDB: priceScheduleDB,
+		currency:        currency,
+		at:              time.Now(),
+		rates:           map[string]*entity.ExchangeRate{},
+	}, nil
+}
+
+// output wraps product for a response with its effective price, adding the
+// converted price when a currency was requested.
+func (c *priceConverter) output(product *entity.Product) (*dto.ProductOutput, error) {
+	outputs, err := c.outputs([]*entity.Product{product})
+	if err != nil {
+		return nil, err
+	}
+	return outputs[0], nil
+}
+
+// outputs is output for several products, looking their price schedules up
+// at once.
+func (c *priceConverter) outputs(products []*entity.Product) ([]*dto.ProductOutput, error) {
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID.String())
+	}
+	schedules, err := c.priceScheduleDB.FindActive(ids, c.at)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]*dto.ProductOutput, 0, len(products))
+	for _, product := range products {
+		output := &dto.ProductOutput{
+			Product:        product,
+			EffectivePrice: entity.ResolvePrice(product, schedules[product.ID.String()]),
+		}
+		if output.ConvertedPrice, err = c.convert(output.EffectivePrice.Price); err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+// convert returns price in the requested currency, or nil when no currency
+// was requested.
+func (c *priceConverter) convert(price entityPKG.Money) (*dto.ConvertedPriceOutput, error) {
+	if c.currency == "" {
+		return nil, nil
+	}
+	if price.Currency == c.currency {
+		return &dto.ConvertedPriceOutput{Price: price, Rate: "1"}, nil
+	}
+
+	rate, ok := c.rates[price.Currency]
+	if !ok {
+		var err error
+		rate, err = c.exchangeRateDB.FindLatest(price.Currency, c.currency, c.at)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w from %s to %s", errRateNotFound, price.Currency, c.currency)
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.rates[price.Currency] = rate
+	}
+
+	converted, err := rate.Convert(price)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ConvertedPriceOutput{Price: converted, Rate: rate.Rate, RateDate: &rate.Date}, nil
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/go-chi/chi"
+	"gorm.io/gorm"
+)
+
+var errPriceScheduleInPast = errors.New("effective_from must not be in the past")
+
+type PriceScheduleHandler struct {
+	ProductDB       database.ProductInterface
+	PriceScheduleDB database.PriceScheduleInterface
+}
+
+func NewPriceScheduleHandler(productDB database.ProductInterface, priceScheduleDB database.PriceScheduleInterface) *PriceScheduleHandler {
+	return &PriceScheduleHandler{
+		ProductDB:       productDB,
+		PriceScheduleDB: priceScheduleDB,
+	}
+}
+
+// Create Price Schedule godoc
+// @Summary Schedule a product price
+// @Description Schedule a price that replaces the product price from effective_from until effective_to, or for good when effective_to is omitted. The price must be in the currency of the product price. Windows include their start and exclude their end, must not start in the past and must not overlap the other schedules of the product. Only the owner of the product or an admin can do it.
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param request body dto.PriceScheduleInput true "price schedule request"
+// @Success 201 {object} entity.PriceSchedule
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/price-schedules [post]
+// @Security ApiKeyAuth
+func (psh *PriceScheduleHandler) CreatePriceSchedule(w http.ResponseWriter, r *http.Request) {
+	productID, err := entityPKG.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !checkProductOwnerByID(w, r, psh.ProductDB, productID.String()) {
+		return
+	}
+
+	var input dto.PriceScheduleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	// A minute of leeway lets clients schedule a change for "now".
+	if input.EffectiveFrom.Before(time.Now().Add(-time.Minute)) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: errPriceScheduleInPast.Error()})
+		return
+	}
+
+	schedule, err := entity.NewPriceSchedule(productID, input.Price, input.EffectiveFrom, input.EffectiveTo)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := psh.PriceScheduleDB.Create(schedule); err != nil {
+		handlePriceScheduleWriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// List Price Schedules godoc
+// @Summary List product price schedules
+// @Description List the price schedules of a product, past and upcoming, by start time
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Success 200 {array} entity.PriceSchedule
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/price-schedules [get]
+// @Security ApiKeyAuth
+func (psh *PriceScheduleHandler) GetPriceSchedules(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	schedules, err := psh.PriceScheduleDB.FindByProduct(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// Delete Price Schedule godoc
+// @Summary Cancel a price schedule
+// @Description Cancel a price schedule that has not started yet. Schedules that started are part of the price history and cannot be removed. Only the owner of the product or an admin can do it.
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param scheduleID path string true "price schedule ID" Format(uuid)
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/price-schedules/{scheduleID} [delete]
+// @Security ApiKeyAuth
+func (psh *PriceScheduleHandler) DeletePriceSchedule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	scheduleID := chi.URLParam(r, "scheduleID")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if _, err := entityPKG.ParseID(scheduleID); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !checkProductOwnerByID(w, r, psh.ProductDB, id) {
+		return
+	}
+
+	if err := psh.PriceScheduleDB.Delete(id, scheduleID, time.Now()); err != nil {
+		handlePriceScheduleWriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Get Price Timeline godoc
+// @Summary Get the price timeline of a product
+// @Description List the periods during which the product had each price, oldest first, combining changes of its price with its price schedules. The last period has no end.
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Success 200 {array} entity.PriceTimelineEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/prices [get]
+// @Security ApiKeyAuth
+func (psh *PriceScheduleHandler) GetPriceTimeline(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	productDB := psh.ProductDB.ForTenant(claimString(r, "tid"))
+	product, err := productDB.FindById(id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	history, err := productDB.FindHistory(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	schedules, err := psh.PriceScheduleDB.FindByProduct(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	points := entity.PricePoints(history)
+	// Products created before the history was kept only know their current
+	// price.
+	if len(points) == 0 {
+		points = []entity.PricePoint{{Price: product.Price, From: product.CreatedAt}}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entity.BuildPriceTimeline(points, schedules))
+}
+
+func handlePriceScheduleWriteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, entity.ErrPriceScheduleCurrency):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	case errors.Is(err, entity.ErrOverlappingPriceSchedule),
+		errors.Is(err, database.ErrPriceScheduleStarted):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	default:
+		fmt.Println("error to write price schedule", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
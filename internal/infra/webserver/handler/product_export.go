@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	"github.com/FreitasGabriel/fullcycle-api/pkg/xlsx"
+)
+
+// exportBatchSize is how many products an export reads from the database at
+// a time.
+const exportBatchSize = 500
+
+// exportColumns are the columns of CSV and XLSX exports. The first six match
+// the columns of a CSV import.
+var exportColumns = []string{"id", "name", "price", "currency", "category_id", "tags", "created_at"}
+
+var exportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+	"xlsx":   xlsx.ContentType,
+}
+
+// productExporter writes products in one of the export formats.
+type productExporter interface {
+	Write(product *entity.Product) error
+	// Flush sends the products written so far to the client.
+	Flush() error
+	Close() error
+}
+
+// Export Products godoc
+// @Summary Export products
+// @Description Download every product matching the listing filters as CSV, NDJSON or XLSX. Products are streamed in batches, oldest first unless sort is desc. CSV and XLSX tags are separated by "|", like in imports. CSV names and tags starting with =, +, - or @ are prefixed with ' so spreadsheets do not run them as formulas.
+// @Tags products
+// @Produce text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "file format" Enums(csv, ndjson, xlsx) default(csv)
+// @Param sort query string false "created_at order" Enums(asc, desc, created_at, -created_at)
+// @Param name query string false "name contains"
+// @Param name_prefix query string false "name starts with"
+// @Param price_currency query string false "only products priced in this currency, also used to read min_price and max_price (defaults to BRL for them)"
+// @Param min_price query string false "minimum base price as a decimal, ignoring price schedules; only products priced in the currency of the bound match"
+// @Param max_price query string false "maximum base price as a decimal, ignoring price schedules; only products priced in the currency of the bound match"
+// @Param created_after query string false "created after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "created before (RFC 3339 or YYYY-MM-DD)"
+// @Param category query string false "category ID, also matches its subcategories" Format(uuid)
+// @Param tag query []string false "tag, repeat to require several" collectionFormat(multi)
+// @Param owner query string false "owner user ID, or me for the products of the user making the request"
+// @Param status query string false "publication status; only admins can list products that are not published, and by default they see every status" Enums(draft, published, archived)
+// @Success 200 {file} file
+// @Header 200 {string} Content-Disposition "attachment with the file name"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/export [get]
+// @Security ApiKeyAuth
+func (ph *ProductHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: fmt.Sprintf("invalid format: %q, expected csv, ndjson or xlsx", format)})
+		return
+	}
+
+	sort, err := database.ParseProductSort(r.URL.Query().Get("sort"))
+	if err == nil && !sort.SupportsCursor() {
+		err = database.ErrUnsupportedSortField
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		writeFilterError(w, err)
+		return
+	}
+
+	productDB := ph.productDB(r)
+	products, cursor, err := productDB.FindAllAfter(nil, exportBatchSize, sort, filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("products-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.WriteHeader(http.StatusOK)
+
+	exporter, err := newProductExporter(format, w)
+	if err != nil {
+		fmt.Println("error to start export", err)
+		return
+	}
+	// From here on the status is sent, so errors can only cut the file
+	// short. Leaving it unclosed makes the truncation visible, as a broken
+	// archive for XLSX.
+	for {
+		for _, product := range products {
+			if err := exporter.Write(product); err != nil {
+				fmt.Println("error to export product", err)
+				return
+			}
+		}
+		if err := exporter.Flush(); err != nil {
+			fmt.Println("error to export products", err)
+			return
+		}
+		http.NewResponseController(w).Flush()
+		if cursor == nil {
+			break
+		}
+
+		products, cursor, err = productDB.FindAllAfter(cursor, exportBatchSize, sort, filter)
+		if err != nil {
+			fmt.Println("error to export products", err)
+			return
+		}
+	}
+	if err := exporter.Close(); err != nil {
+		fmt.Println("error to export products", err)
+	}
+}
+
+func newProductExporter(format string, w io.Writer) (productExporter, error) {
+	switch format {
+	case "ndjson":
+		buf := bufio.NewWriter(w)
+		return &ndjsonExporter{buf: buf, encoder: json.NewEncoder(buf)}, nil
+	case "xlsx":
+		writer, err := xlsx.NewWriter(w, "Products")
+		if err != nil {
+			return nil, err
+		}
+		return &xlsxExporter{writer: writer}, writer.WriteRow(xlsxText(exportColumns)...)
+	default:
+		exporter := &csvExporter{writer: csv.NewWriter(w)}
+		return exporter, exporter.writer.Write(exportColumns)
+	}
+}
+
+// exportRecord returns the values of the exportColumns of product.
+func exportRecord(product *entity.Product) []string {
+	categoryID := ""
+	if product.CategoryID != nil {
+		categoryID = product.CategoryID.String()
+	}
+	tags := make([]string, 0, len(product.Tags))
+	for _, tag := range product.Tags {
+		tags = append(tags, tag.Name)
+	}
+	return []string{
+		product.ID.String(),
+		product.Name,
+		product.Price.String(),
+		product.Price.Currency,
+		categoryID,
+		strings.Join(tags, csvTagSeparator),
+		product.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+type csvExporter struct {
+	writer *csv.Writer
+}
+
+func (e *csvExporter) Write(product *entity.Product) error {
+	record := exportRecord(product)
+	// Spreadsheets open CSV files by evaluating cells that look like
+	// formulas, so the free text columns must not start like one. XLSX
+	// cells are typed as text and need no escaping.
+	record[1] = csvEscapeFormula(record[1])
+	record[5] = csvEscapeFormula(record[5])
+	return e.writer.Write(record)
+}
+
+// csvEscapeFormula prefixes value with a quote when it starts like a
+// spreadsheet formula.
+func csvEscapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (e *csvExporter) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExporter) Close() error {
+	return e.Flush()
+}
+
+type ndjsonExporter struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExporter) Write(product *entity.Product) error {
+	return e.encoder.Encode(product)
+}
+
+func (e *ndjsonExporter) Flush() error {
+	return e.buf.Flush()
+}
+
+func (e *ndjsonExporter) Close() error {
+	return e.Flush()
+}
+
+type xlsxExporter struct {
+	writer *xlsx.Writer
+}
+
+func (e *xlsxExporter) Write(product *entity.Product) error {
+	record := exportRecord(product)
+	cells := xlsxText(record)
+	// The price column holds an exact decimal, so spreadsheets can sum it.
+	cells[2] = xlsx.Number(record[2])
+	return e.writer.WriteRow(cells...)
+}
+
+func xlsxText(values []string) []xlsx.Cell {
+	cells := make([]xlsx.Cell, len(values))
+	for i, value := range values {
+		cells[i] = xlsx.Text(value)
+	}
+	return cells
+}
+
+func (e *xlsxExporter) Flush() error {
+	return e.writer.Flush()
+}
+
+func (e *xlsxExporter) Close() error {
+	return e.writer.Close()
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"testing"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportEscapesFormulasInCSV(t *testing.T) {
+	name := `=HYPERLINK("http://example.com","click")`
+	product, _ := entity.NewProduct(name, entityPKG.Money{Amount: 1000, Currency: "BRL"})
+	product.SetTags([]string{"@sale"})
+
+	var buf bytes.Buffer
+	exporter, err := newProductExporter("csv", &buf)
+	assert.NoError(t, err)
+	assert.NoError(t, exporter.Write(product))
+	assert.NoError(t, exporter.Close())
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "'"+name, records[1][1])
+	assert.Equal(t, "'@sale", records[1][5])
+
+	buf.Reset()
+	exporter, err = newProductExporter("xlsx", &buf)
+	assert.NoError(t, err)
+	assert.NoError(t, exporter.Write(product))
+	assert.NoError(t, exporter.Close())
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	sheet, err := archive.Open("xl/worksheets/sheet1.xml")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(sheet)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `<t xml:space="preserve">=HYPERLINK(`)
+	assert.Contains(t, string(content), `<t xml:space="preserve">@sale</t>`)
+}
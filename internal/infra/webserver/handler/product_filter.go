@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+// ownerMe is the owner query parameter value that stands for the user
+// making the request.
+const ownerMe = "me"
+
+var errProductStatusForAdmins = errors.New("only admins can list products that are not published")
+
+// parseProductFilter reads the product filters of a listing. Callers that
+// are not admins only ever see published products.
+func parseProductFilter(r *http.Request) (database.ProductFilter, error) {
+	query := r.URL.Query()
+	filter := database.ProductFilter{
+		Name:       query.Get("name"),
+		NamePrefix: query.Get("name_prefix"),
+	}
+
+	var err error
+	currency := entityPKG.DefaultCurrency
+	if filter.Currency = query.Get("price_currency"); filter.Currency != "" {
+		if !entityPKG.IsCurrency(filter.Currency) {
+			return filter, fmt.Errorf("invalid price_currency: %q", filter.Currency)
+		}
+		currency = filter.Currency
+	}
+	if filter.MinPrice, err = parsePriceParam(query.Get("min_price"), "min_price", currency); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = parsePriceParam(query.Get("max_price"), "max_price", currency); err != nil {
+		return filter, err
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, fmt.Errorf("min_price must not be greater than max_price")
+	}
+	// Amounts in different currencies cannot be compared, so price bounds
+	// only match products priced in the currency they were read in.
+	if filter.MinPrice != nil || filter.MaxPrice != nil {
+		filter.Currency = currency
+	}
+	if filter.CreatedAfter, err = parseTimeParam(query.Get("created_after"), "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseTimeParam(query.Get("created_before"), "created_before"); err != nil {
+		return filter, err
+	}
+	if filter.CategoryID = query.Get("category"); filter.CategoryID != "" {
+		if _, err := entityPKG.ParseID(filter.CategoryID); err != nil {
+			return filter, fmt.Errorf("invalid category: %q", filter.CategoryID)
+		}
+	}
+	switch owner := query.Get("owner"); owner {
+	case "":
+	case ownerMe:
+		if filter.OwnerID = claimString(r, "sub"); filter.OwnerID == "" {
+			return filter, fmt.Errorf("owner=%s needs a token issued to a user", ownerMe)
+		}
+	default:
+		if _, err := entityPKG.ParseID(owner); err != nil {
+			return filter, fmt.Errorf("invalid owner: %q, expected a user ID or %s", owner, ownerMe)
+		}
+		filter.OwnerID = owner
+	}
+	if value := query.Get("min_rating"); value != "" {
+		rating, err := strconv.ParseFloat(value, 64)
+		if err != nil || !(rating >= entity.MinStars && rating <= entity.MaxStars) {
+			return filter, fmt.Errorf("invalid min_rating: %q, expected a number from %d to %d", value, entity.MinStars, entity.MaxStars)
+		}
+		filter.MinRating = &rating
+	}
+	switch status := query.Get("status"); {
+	case status == "":
+		if !isAdmin(r) {
+			filter.Status = entity.ProductPublished
+		}
+	case !entity.IsProductStatus(status):
+		return filter, fmt.Errorf("invalid status: %q, expected draft, published or archived", status)
+	case status != entity.ProductPublished && !isAdmin(r):
+		return filter, errProductStatusForAdmins
+	default:
+		filter.Status = status
+	}
+	for _, tag := range entity.NewTags(query["tag"]) {
+		filter.Tags = append(filter.Tags, tag.Name)
+	}
+
+	return filter, nil
+}
+
+// writeFilterError answers a listing whose filters parseProductFilter
+// rejected.
+func writeFilterError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errProductStatusForAdmins) {
+		w.WriteHeader(http.StatusForbidden)
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+}
+
+// parsePriceParam parses a decimal price into minor units of currency.
+func parsePriceParam(value, name, currency string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	price, err := entityPKG.ParseMoney(value, currency)
+	if err != nil || price.IsNegative() {
+		return nil, fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return &price.Amount, nil
+}
+
+func parseTimeParam(value, name string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s: %q, expected RFC 3339 or YYYY-MM-DD", name, value)
+}
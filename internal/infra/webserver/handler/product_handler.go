@@ -1,36 +1,89 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
 	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
 	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
 	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/FreitasGabriel/fullcycle-api/pkg/jsonpatch"
 	"github.com/go-chi/chi"
+	"gorm.io/gorm"
 )
 
+const defaultPageSize = 10
+
 type ProductHandler struct {
-	ProductDB database.ProductInterface
+	ProductDB       database.ProductInterface
+	ExchangeRateDB  database.ExchangeRateInterface
+	VariantDB       database.VariantInterface
+	PriceScheduleDB database.PriceScheduleInterface
+	MaxPageSize     int
 }
 
-func NewProductHandler(productDB database.ProductInterface) *ProductHandler {
+func NewProductHandler(productDB database.ProductInterface, exchangeRateDB database.ExchangeRateInterface, variantDB database.VariantInterface, priceScheduleDB database.PriceScheduleInterface, maxPageSize int) *ProductHandler {
+	if maxPageSize < 1 {
+		maxPageSize = 100
+	}
 	return &ProductHandler{
-		ProductDB: productDB,
+		ProductDB:       productDB,
+		ExchangeRateDB:  exchangeRateDB,
+		VariantDB:       variantDB,
+		PriceScheduleDB: priceScheduleDB,
+		MaxPageSize:     maxPageSize,
+	}
+}
+
+// priceConverter reads the currency query parameter, writing a 400 response
+// and returning false when it is not a supported currency.
+func (ph *ProductHandler) priceConverter(w http.ResponseWriter, r *http.Request) (*priceConverter, bool) {
+	converter, err := newPriceConverter(ph.ExchangeRateDB, ph.PriceScheduleDB, r.URL.Query().Get("currency"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return nil, false
 	}
+	return converter, true
+}
+
+func writeConvertError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errRateNotFound) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+	fmt.Println("error to convert price", err)
+	w.WriteHeader(http.StatusInternalServerError)
+}
+
+// productDB returns the repository bound to the user making the request, so
+// it only sees the products of their organization and the changes it
+// records are attributed to them.
+func (ph *ProductHandler) productDB(r *http.Request) database.ProductInterface {
+	return ph.ProductDB.ForTenant(claimString(r, "tid")).WithActor(claimString(r, "sub"))
 }
 
 // Create Product godoc
 // @Summary Create product
-// @Description Create product
+// @Description Create a product as a draft. Drafts are only listed to admins until the product is published.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param request body dto.CreateProductInput true "product request"
 // @Success 201
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /products [post]
 // @Security ApiKeyAuth
@@ -47,8 +100,28 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	p.CategoryID = product.CategoryID
+	p.OwnerID = claimString(r, "sub")
+	p.SetTags(product.Tags)
+	p.Slug = product.Slug
+	p.SetSKU(product.SKU)
+	if err := p.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
 
-	err = h.ProductDB.Create(p)
+	err = h.productDB(r).Create(p)
+	if errors.Is(err, database.ErrCategoryNotFound) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+	if errors.Is(err, database.ErrDuplicateSlug) || errors.Is(err, database.ErrDuplicateSKU) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -59,13 +132,18 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 
 // Get Product godoc
 // @Summary Get a product
-// @Description Get a product
+// @Description Get a product with its effective price, which comes from the price schedule that applies now, if any, and the average rating and count of its approved reviews
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path string true "product ID" Format(uuid)
-// @Success 200 {object} entity.Product
+// @Param currency query string false "also return the price converted into this currency"
+// @Param include query string false "set to variants to embed the product variants" Enums(variants)
+// @Success 200 {object} dto.ProductOutput
+// @Header 200 {string} ETag "product version, send it back in If-Match"
+// @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
 // @Router /products/{id} [get]
 // @Security ApiKeyAuth
 func (ph *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
@@ -75,28 +153,147 @@ func (ph *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := ph.ProductDB.FindById(id)
+	product, err := ph.productDB(r).FindById(id)
 	if err != nil {
 		fmt.Println("err", err)
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	ph.writeProduct(w, r, product)
+}
+
+// Get Product By Slug godoc
+// @Summary Get a product by slug
+// @Description Get a product by its slug, like GET /products/{id}. Slugs the product used before redirect with 301 to its current slug.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param slug path string true "product slug"
+// @Param currency query string false "also return the price converted into this currency"
+// @Param include query string false "set to variants to embed the product variants" Enums(variants)
+// @Success 200 {object} dto.ProductOutput
+// @Header 200 {string} ETag "product version, send it back in If-Match"
+// @Header 301 {string} Location "path of the current slug"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /products/by-slug/{slug} [get]
+// @Security ApiKeyAuth
+func (ph *ProductHandler) GetProductBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	product, err := ph.productDB(r).FindBySlug(slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		product, err = ph.productDB(r).FindSlugRedirect(slug)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		location := "/products/by-slug/" + url.PathEscape(product.Slug)
+		if r.URL.RawQuery != "" {
+			location += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, location, http.StatusMovedPermanently)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	ph.writeProduct(w, r, product)
+}
+
+// Get Product By SKU godoc
+// @Summary Get a product by SKU
+// @Description Get a product by its SKU, like GET /products/{id}
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param sku path string true "product SKU"
+// @Param currency query string false "also return the price converted into this currency"
+// @Param include query string false "set to variants to embed the product variants" Enums(variants)
+// @Success 200 {object} dto.ProductOutput
+// @Header 200 {string} ETag "product version, send it back in If-Match"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /products/by-sku/{sku} [get]
+// @Security ApiKeyAuth
+func (ph *ProductHandler) GetProductBySKU(w http.ResponseWriter, r *http.Request) {
+	product, err := ph.productDB(r).FindBySKU(chi.URLParam(r, "sku"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	ph.writeProduct(w, r, product)
+}
+
+// writeProduct answers with product, its effective price and what the
+// currency and include query parameters ask for.
+func (ph *ProductHandler) writeProduct(w http.ResponseWriter, r *http.Request, product *entity.Product) {
+	include := r.URL.Query().Get("include")
+	if include != "" && include != "variants" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: fmt.Sprintf("invalid include: %q", include)})
+		return
+	}
+
+	converter, ok := ph.priceConverter(w, r)
+	if !ok {
+		return
+	}
+
+	output, err := converter.output(product)
+	if err != nil {
+		writeConvertError(w, err)
+		return
+	}
+
+	if include == "variants" {
+		output.Variants, err = ph.VariantDB.FindByProduct(product.ID.String())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(product.Version))
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(product)
+	json.NewEncoder(w).Encode(output)
 }
 
 // List Products godoc
 // @Summary List Products
-// @Description get all products
+// @Description get all products, each with its effective price. Only published products are listed unless the caller is an admin
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param page query string false "page number"
 // @Param limit query string false "limit"
-// @Params order query string false "ordenation"
-// @Success 200 {object} entity.Product
+// @Param sort query string false "comma separated fields (created_at, id, name, price, rating), prefix with - for descending; price sorts by the base price, ignoring price schedules, and groups products by currency first"
+// @Param name query string false "name contains"
+// @Param name_prefix query string false "name starts with"
+// @Param price_currency query string false "only products priced in this currency, also used to read min_price and max_price (defaults to BRL for them)"
+// @Param min_price query string false "minimum base price as a decimal, ignoring price schedules; only products priced in the currency of the bound match"
+// @Param max_price query string false "maximum base price as a decimal, ignoring price schedules; only products priced in the currency of the bound match"
+// @Param created_after query string false "created after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "created before (RFC 3339 or YYYY-MM-DD)"
+// @Param category query string false "category ID, also matches its subcategories" Format(uuid)
+// @Param tag query []string false "tag, repeat to require several" collectionFormat(multi)
+// @Param owner query string false "owner user ID, or me for the products of the user making the request"
+// @Param status query string false "publication status; only admins can list products that are not published, and by default they see every status" Enums(draft, published, archived)
+// @Param min_rating query number false "minimum average rating, from 1 to 5"
+// @Param cursor query string false "keyset cursor; send it empty to start and then the returned next_cursor"
+// @Param currency query string false "also return prices converted into this currency"
+// @Success 200 {object} dto.ProductListOutput
+// @Header 200 {integer} X-Total-Count "total number of matching products"
+// @Header 200 {string} Link "RFC 8288 pagination links"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /products/ [get]
@@ -104,90 +301,357 @@ func (ph *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 func (ph *ProductHandler) GetAllProducts(w http.ResponseWriter, r *http.Request) {
 	page := r.URL.Query().Get("page")
 	limit := r.URL.Query().Get("limit")
-	sort := r.URL.Query().Get("sort")
 
 	pageInt, err := strconv.Atoi(page)
-	if err != nil {
-		pageInt = 0
+	if err != nil || pageInt < 1 {
+		pageInt = 1
 	}
 	limitInt, err := strconv.Atoi(limit)
+	if err != nil || limitInt < 1 {
+		limitInt = defaultPageSize
+	}
+	limitInt = min(limitInt, ph.MaxPageSize)
+
+	sort, err := database.ParseProductSort(r.URL.Query().Get("sort"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		writeFilterError(w, err)
+		return
+	}
+
+	converter, ok := ph.priceConverter(w, r)
+	if !ok {
+		return
+	}
+
+	cursorMode := r.URL.Query().Has("cursor")
+	if cursorMode && !sort.SupportsCursor() {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: database.ErrUnsupportedSortField.Error()})
+		return
+	}
+
+	total, err := ph.productDB(r).Count(filter)
 	if err != nil {
-		limitInt = 10
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
-	products, err := ph.ProductDB.FindAll(pageInt, limitInt, sort)
+	if cursorMode {
+		ph.getProductsAfter(w, r, limitInt, sort, filter, total, converter)
+		return
+	}
+
+	products, err := ph.productDB(r).FindAll(pageInt, limitInt, sort, filter)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	items, err := converter.outputs(products)
+	if err != nil {
+		writeConvertError(w, err)
+		return
+	}
+
+	output := dto.ProductListOutput{
+		Items:   items,
+		Page:    pageInt,
+		Limit:   limitInt,
+		Total:   total,
+		HasNext: int64(pageInt*limitInt) < total,
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	if links := pageLinks(r.URL, pageInt, limitInt, total); links != "" {
+		w.Header().Set("Link", links)
+	}
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(products)
+	json.NewEncoder(w).Encode(output)
 
 }
 
+func (ph *ProductHandler) getProductsAfter(w http.ResponseWriter, r *http.Request, limit int, sort database.ProductSort, filter database.ProductFilter, total int64, converter *priceConverter) {
+	var cursor *database.ProductCursor
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		var err error
+		cursor, err = database.DecodeProductCursor(c)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+			return
+		}
+	}
+
+	products, next, err := ph.productDB(r).FindAllAfter(cursor, limit, sort, filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	items, err := converter.outputs(products)
+	if err != nil {
+		writeConvertError(w, err)
+		return
+	}
+
+	output := dto.ProductListOutput{
+		Items:   items,
+		Limit:   limit,
+		Total:   total,
+		HasNext: next != nil,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	if next != nil {
+		output.NextCursor = next.Encode()
+		w.Header().Set("Link", cursorLink(r.URL, output.NextCursor))
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(output)
+}
+
 // Update Product godoc
 // @Summary Update a product
-// @Description Update a product
+// @Description Update a product. Requires the product ETag in If-Match. Only the owner of the product or an admin can update it.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path string true "product ID" Format(uuid)
-// @Param request body dto.CreateProductInput true "product request"
+// @Param If-Match header string true "product ETag"
+// @Param request body dto.UpdateProductInput true "product request"
 // @Success 200
+// @Header 200 {string} ETag "new product version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /products/{id} [put]
 // @Security ApiKeyAuth
 func (ph *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	if id == "" {
+	if _, err := entityPKG.ParseID(id); err != nil {
+		fmt.Println("error to parse id", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	var product entity.Product
-	err := json.NewDecoder(r.Body).Decode(&product)
+	var input dto.UpdateProductInput
+	err := json.NewDecoder(r.Body).Decode(&input)
 	if err != nil {
 		fmt.Println("error to decode body request", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	product.ID, err = entityPKG.ParseID(id)
+	product, err := ph.productDB(r).FindById(id)
 	if err != nil {
-		fmt.Println("error to parse id", err)
+		fmt.Println("error to find product", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !checkProductOwner(w, r, product) {
+		return
+	}
+
+	if _, ok := checkIfMatch(w, r, product.Version); !ok {
+		return
+	}
+
+	product.Name = input.Name
+	product.Price = input.Price
+	product.CategoryID = input.CategoryID
+	product.SetTags(input.Tags)
+	if input.Slug != "" {
+		product.Slug = input.Slug
+	}
+	product.SetSKU(input.SKU)
+	if err := product.Validate(); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
 		return
 	}
 
-	_, err = ph.ProductDB.FindById(id)
+	err = ph.productDB(r).Update(product)
 	if err != nil {
-		fmt.Println("error to find product", err)
+		ph.handleWriteError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(product.Version))
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleWriteError maps errors from conditional repository writes.
+func (ph *ProductHandler) handleWriteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrVersionConflict):
+		w.WriteHeader(http.StatusPreconditionFailed)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: errStaleVersion.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
 		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, database.ErrCategoryNotFound):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	case errors.Is(err, database.ErrDuplicateSlug), errors.Is(err, database.ErrDuplicateSKU):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	default:
+		fmt.Println("error to write product", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// Patch Product godoc
+// @Summary Partially update a product
+// @Description Apply a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) to a product. Only changed fields are written. Requires the product ETag in If-Match. Only the owner of the product or an admin can patch it. The status changes through the publish, unpublish and archive routes.
+// @Tags products
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param If-Match header string true "product ETag"
+// @Param request body object true "merge patch document or array of patch operations"
+// @Success 200 {object} entity.Product
+// @Header 200 {string} ETag "new product version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 415 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id} [patch]
+// @Security ApiKeyAuth
+func (ph *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	err = ph.ProductDB.Update(&product)
+	var applyPatch func(doc, patch []byte) ([]byte, error)
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case jsonpatch.MergePatchContentType:
+		applyPatch = jsonpatch.MergePatch
+	case jsonpatch.JSONPatchContentType:
+		applyPatch = jsonpatch.Apply
+	default:
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: fmt.Sprintf("content type must be %s or %s", jsonpatch.MergePatchContentType, jsonpatch.JSONPatchContentType)})
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	product, err := ph.productDB(r).FindById(id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !checkProductOwner(w, r, product) {
+		return
+	}
+
+	if _, ok := checkIfMatch(w, r, product.Version); !ok {
+		return
+	}
+
+	doc, err := json.Marshal(product)
 	if err != nil {
-		fmt.Println("error to update product", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	doc, err = applyPatch(doc, patch)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			status = http.StatusConflict
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	var patched entity.Product
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
 
+	if patched.ID != product.ID || patched.OwnerID != product.OwnerID || !patched.CreatedAt.Equal(product.CreatedAt) ||
+		patched.Version != product.Version || patched.DeletedAt.Valid != product.DeletedAt.Valid ||
+		patched.ReviewCount != product.ReviewCount || patched.AverageRating != product.AverageRating ||
+		patched.Status != product.Status || !sameTime(patched.PublishedAt, product.PublishedAt) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: "id, owner_id, created_at, version, deleted_at, review_count, average_rating, status and published_at are read-only"})
+		return
+	}
+	patched.CreatedAt = product.CreatedAt
+	patched.PublishedAt = product.PublishedAt
+	patched.RatingSum = product.RatingSum
+	patched.DeletedAt = product.DeletedAt
+	patched.TenantID = product.TenantID
+
+	if err := patched.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	err = ph.productDB(r).UpdateFields(&patched, product.ChangedFields(&patched))
+	if err != nil {
+		ph.handleWriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(patched.Version))
 	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(patched)
+}
+
+// sameTime reports whether a and b are both unset or the same instant.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
 }
 
 // Delete Product godoc
 // @Summary Delete a product
-// @Description Delete a product
+// @Description Delete a product. Requires the product ETag in If-Match. Only the owner of the product or an admin can delete it.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path string true "product ID" Format(uuid)
+// @Param If-Match header string true "product ETag"
 // @Success 200
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /products/{id} [delete]
 // @Security ApiKeyAuth
@@ -198,17 +662,194 @@ func (ph *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	_, err := ph.ProductDB.FindById(id)
+	product, err := ph.productDB(r).FindById(id)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	if !checkProductOwner(w, r, product) {
+		return
+	}
+
+	version, ok := checkIfMatch(w, r, product.Version)
+	if !ok {
+		return
+	}
 
-	err = ph.ProductDB.Delete(id)
+	err = ph.productDB(r).Delete(id, version)
+	if err != nil {
+		ph.handleWriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// List Deleted Products godoc
+// @Summary List deleted products
+// @Description List products in the trash, most recently deleted first
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param page query string false "page number"
+// @Param limit query string false "limit"
+// @Param currency query string false "also return prices converted into this currency"
+// @Success 200 {object} dto.ProductListOutput
+// @Failure 400 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/trash [get]
+// @Security ApiKeyAuth
+func (ph *ProductHandler) GetDeletedProducts(w http.ResponseWriter, r *http.Request) {
+	converter, ok := ph.priceConverter(w, r)
+	if !ok {
+		return
+	}
+
+	pageInt, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || pageInt < 1 {
+		pageInt = 1
+	}
+	limitInt, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limitInt < 1 {
+		limitInt = defaultPageSize
+	}
+	limitInt = min(limitInt, ph.MaxPageSize)
+
+	total, err := ph.productDB(r).CountDeleted()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	products, err := ph.productDB(r).FindDeleted(pageInt, limitInt)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	items, err := converter.outputs(products)
+	if err != nil {
+		writeConvertError(w, err)
+		return
+	}
+
+	output := dto.ProductListOutput{
+		Items:   items,
+		Page:    pageInt,
+		Limit:   limitInt,
+		Total:   total,
+		HasNext: int64(pageInt*limitInt) < total,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	if links := pageLinks(r.URL, pageInt, limitInt, total); links != "" {
+		w.Header().Set("Link", links)
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(output)
+}
+
+// Restore Product godoc
+// @Summary Restore a deleted product
+// @Description Move a product out of the trash. Only the owner of the product or an admin can restore it.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Success 200
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/restore [post]
+// @Security ApiKeyAuth
+func (ph *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	product, err := ph.productDB(r).FindDeletedById(id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !checkProductOwner(w, r, product) {
+		return
+	}
+
+	err = ph.productDB(r).Restore(id)
+	if err != nil {
+		ph.handleWriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Purge Product godoc
+// @Summary Permanently delete a product
+// @Description Permanently remove a product from the trash. Admin only.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/trash/{id} [delete]
+// @Security ApiKeyAuth
+func (ph *ProductHandler) PurgeProduct(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err := ph.productDB(r).Purge(id)
+	if err != nil {
+		ph.handleWriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Product History godoc
+// @Summary Product change history
+// @Description List who changed a product, when, and the before and after values, oldest first
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Success 200 {array} entity.ProductHistory
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/history [get]
+// @Security ApiKeyAuth
+func (ph *ProductHandler) GetProductHistory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	history, err := ph.productDB(r).FindHistory(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(history) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(history)
 }
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+)
+
+// csvTagSeparator separates the tags in the tags column of a CSV import.
+const csvTagSeparator = "|"
+
+// maxImportLine is the longest NDJSON line an import accepts.
+const maxImportLine = 1 << 20
+
+// importRow is a parsed data row of an import file. Rows that could not be
+// read carry the reason in err and are rejected without touching products.
+type importRow struct {
+	number int
+	input  dto.ImportProductInput
+	err    error
+}
+
+// importFormat picks the format of an import from the format query
+// parameter, falling back to the Content-Type of the request.
+func importFormat(format, contentType string) (string, error) {
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		switch mediaType {
+		case "text/csv":
+			format = entity.ImportCSV
+		case "application/x-ndjson", "application/jsonl":
+			format = entity.ImportNDJSON
+		}
+	}
+	if format != entity.ImportCSV && format != entity.ImportNDJSON {
+		return "", entity.ErrInvalidImportFormat
+	}
+	return format, nil
+}
+
+func readImportRows(format string, r io.Reader) ([]importRow, error) {
+	if format == entity.ImportCSV {
+		return readCSVRows(r)
+	}
+	return readNDJSONRows(r)
+}
+
+// readCSVRows reads a CSV file whose first line names the columns. name,
+// price and currency are required; id, category_id and tags, separated by
+// csvTagSeparator, are optional.
+func readCSVRows(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "id", "name", "price", "currency", "category_id", "tags":
+		default:
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"name", "price", "currency"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		row := importRow{number: len(rows) + 1}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			row.err = parseErr.Err
+			rows = append(rows, row)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		row.input, row.err = csvProductInput(record, columns)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func csvProductInput(record []string, columns map[string]int) (dto.ImportProductInput, error) {
+	column := func(name string) string {
+		i, ok := columns[name]
+		if !ok {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	input := dto.ImportProductInput{Name: column("name")}
+	price, err := entityPKG.ParseMoney(column("price"), column("currency"))
+	if err != nil {
+		return input, err
+	}
+	input.Price = price
+	if id := column("id"); id != "" {
+		parsed, err := entityPKG.ParseID(id)
+		if err != nil {
+			return input, entity.ErrInvalidID
+		}
+		input.ID = &parsed
+	}
+	if categoryID := column("category_id"); categoryID != "" {
+		parsed, err := entityPKG.ParseID(categoryID)
+		if err != nil {
+			return input, errors.New("invalid category_id")
+		}
+		input.CategoryID = &parsed
+	}
+	if tags := column("tags"); tags != "" {
+		input.Tags = strings.Split(tags, csvTagSeparator)
+	}
+	return input, nil
+}
+
+// readNDJSONRows reads one product per line, in the format of
+// dto.ImportProductInput. Blank lines are skipped.
+func readNDJSONRows(r io.Reader) ([]importRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxImportLine)
+
+	var rows []importRow
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		row := importRow{number: len(rows) + 1}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		row.err = decoder.Decode(&row.input)
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/go-chi/chi"
+)
+
+// Publish Product godoc
+// @Summary Publish a product
+// @Description Make a draft or archived product live, so it is listed to everyone and can be ordered, and record when it was published. Only the owner of the product or an admin can publish it.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Success 200 {object} entity.Product
+// @Header 200 {string} ETag "new product version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/publish [post]
+// @Security ApiKeyAuth
+func (ph *ProductHandler) PublishProduct(w http.ResponseWriter, r *http.Request) {
+	ph.transitionProduct(w, r, entity.ProductPublished)
+}
+
+// Unpublish Product godoc
+// @Summary Unpublish a product
+// @Description Move a published product back to draft. Only the owner of the product or an admin can unpublish it.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Success 200 {object} entity.Product
+// @Header 200 {string} ETag "new product version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/unpublish [post]
+// @Security ApiKeyAuth
+func (ph *ProductHandler) UnpublishProduct(w http.ResponseWriter, r *http.Request) {
+	ph.transitionProduct(w, r, entity.ProductDraft)
+}
+
+// Archive Product godoc
+// @Summary Archive a product
+// @Description Retire a published product from sale and from the listings of non-admins. It can be published again later. Only the owner of the product or an admin can archive it.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Success 200 {object} entity.Product
+// @Header 200 {string} ETag "new product version"
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/archive [post]
+// @Security ApiKeyAuth
+func (ph *ProductHandler) ArchiveProduct(w http.ResponseWriter, r *http.Request) {
+	ph.transitionProduct(w, r, entity.ProductArchived)
+}
+
+// transitionProduct moves the product of the request to status, answering
+// 409 when its current status does not allow it.
+func (ph *ProductHandler) transitionProduct(w http.ResponseWriter, r *http.Request, status string) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	product, err := ph.productDB(r).FindById(id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !checkProductOwner(w, r, product) {
+		return
+	}
+
+	if err := product.Transition(status, time.Now()); err != nil {
+		if errors.Is(err, entity.ErrInvalidProductTransition) {
+			w.WriteHeader(http.StatusConflict)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	err = ph.productDB(r).UpdateFields(product, []string{"Status", "PublishedAt"})
+	if err != nil {
+		ph.handleWriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(product.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(product)
+}
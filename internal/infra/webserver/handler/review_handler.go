@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/go-chi/chi"
+	"gorm.io/gorm"
+)
+
+var (
+	errNotReviewAuthor        = errors.New("only the author can change or delete a review")
+	errReviewStatusForAdmins  = errors.New("only admins can list reviews that are not approved")
+	errReviewAuthorIsRequired = errors.New("reviews need a token issued to a user")
+)
+
+type ReviewHandler struct {
+	ReviewDB database.ReviewInterface
+}
+
+func NewReviewHandler(reviewDB database.ReviewInterface) *ReviewHandler {
+	return &ReviewHandler{ReviewDB: reviewDB}
+}
+
+// List Reviews godoc
+// @Summary List product reviews
+// @Description List the approved reviews of a product, newest first. Admins can list the reviews in another moderation status, or in any status with status=all.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param status query string false "moderation status, admin only" Enums(pending, approved, rejected, all)
+// @Success 200 {array} entity.Review
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/reviews [get]
+// @Security ApiKeyAuth
+func (rh *ReviewHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = entity.ReviewApproved
+	case "all":
+		status = ""
+	default:
+		if !entity.IsReviewStatus(status) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Message: entity.ErrInvalidReviewStatus.Error()})
+			return
+		}
+	}
+	if status != entity.ReviewApproved && !isAdmin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: errReviewStatusForAdmins.Error()})
+		return
+	}
+
+	reviews, err := rh.ReviewDB.FindByProduct(id, status)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(reviews)
+}
+
+// Create Review godoc
+// @Summary Review a product
+// @Description Review a product as the user making the request. Each user reviews a product once. Reviews are pending until a moderator approves them and only approved reviews count towards the product rating.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param request body dto.ReviewInput true "review request"
+// @Success 201 {object} entity.Review
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/reviews [post]
+// @Security ApiKeyAuth
+func (rh *ReviewHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
+	productID, err := entityPKG.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	authorID := claimString(r, "sub")
+	if authorID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: errReviewAuthorIsRequired.Error()})
+		return
+	}
+
+	var input dto.ReviewInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	review, err := entity.NewReview(productID, authorID, input.Stars, input.Title, input.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := rh.ReviewDB.Create(review); err != nil {
+		handleReviewWriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(review)
+}
+
+// Update Review godoc
+// @Summary Edit a review
+// @Description Replace the stars, title and body of a review. Only its author can edit it, and the edited review goes back to pending moderation.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param reviewID path string true "review ID" Format(uuid)
+// @Param request body dto.ReviewInput true "review request"
+// @Success 200 {object} entity.Review
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/reviews/{reviewID} [put]
+// @Security ApiKeyAuth
+func (rh *ReviewHandler) UpdateReview(w http.ResponseWriter, r *http.Request) {
+	review, ok := rh.findReview(w, r)
+	if !ok {
+		return
+	}
+	if review.AuthorID != claimString(r, "sub") {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: errNotReviewAuthor.Error()})
+		return
+	}
+
+	var input dto.ReviewInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := review.Edit(input.Stars, input.Title, input.Body, time.Now()); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := rh.ReviewDB.Update(review); err != nil {
+		handleReviewWriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(review)
+}
+
+// Delete Review godoc
+// @Summary Delete a review
+// @Description Delete a review and take it out of the product rating. Only its author can delete it.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param reviewID path string true "review ID" Format(uuid)
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/reviews/{reviewID} [delete]
+// @Security ApiKeyAuth
+func (rh *ReviewHandler) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	review, ok := rh.findReview(w, r)
+	if !ok {
+		return
+	}
+	if review.AuthorID != claimString(r, "sub") {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: errNotReviewAuthor.Error()})
+		return
+	}
+
+	if err := rh.ReviewDB.Delete(review.ProductID.String(), review.ID.String()); err != nil {
+		handleReviewWriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Moderate Review godoc
+// @Summary Moderate a review
+// @Description Set the moderation status of a review. Approving a review adds it to the product rating and moving it out of approved takes it out. Admin only.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param reviewID path string true "review ID" Format(uuid)
+// @Param request body dto.ReviewStatusInput true "status request"
+// @Success 200 {object} entity.Review
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/reviews/{reviewID}/status [put]
+// @Security ApiKeyAuth
+func (rh *ReviewHandler) ModerateReview(w http.ResponseWriter, r *http.Request) {
+	var input dto.ReviewStatusInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	review, ok := rh.findReview(w, r)
+	if !ok {
+		return
+	}
+	if err := review.Moderate(input.Status, time.Now()); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := rh.ReviewDB.Update(review); err != nil {
+		handleReviewWriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(review)
+}
+
+// findReview loads the review of the request, writing a 400 or 404 response
+// and returning false when the IDs are invalid or it does not exist.
+func (rh *ReviewHandler) findReview(w http.ResponseWriter, r *http.Request) (*entity.Review, bool) {
+	id := chi.URLParam(r, "id")
+	reviewID := chi.URLParam(r, "reviewID")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+	if _, err := entityPKG.ParseID(reviewID); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+	review, err := rh.ReviewDB.FindById(id, reviewID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return nil, false
+	}
+	return review, true
+}
+
+func handleReviewWriteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, database.ErrAlreadyReviewed), errors.Is(err, database.ErrReviewConflict):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	default:
+		fmt.Println("error to write review", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -9,32 +10,42 @@ import (
 	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
 	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
 	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
 	"github.com/go-chi/jwtauth"
 )
 
+var (
+	errOrganizationRequired = errors.New("organization_id is required, the user is a member of several organizations")
+	errNotMember            = errors.New("user is not a member of the organization")
+)
+
 type UserHandler struct {
-	UserDB       database.UserInterface
-	JWTExpiresIn int
+	UserDB         database.UserInterface
+	OrganizationDB database.OrganizationInterface
+	JWTExpiresIn   int
 }
 
 type ErrorResponse struct {
 	Message string
 }
 
-func NewUserHandler(userDB database.UserInterface) *UserHandler {
+func NewUserHandler(userDB database.UserInterface, organizationDB database.OrganizationInterface) *UserHandler {
 	return &UserHandler{
-		UserDB: userDB,
+		UserDB:         userDB,
+		OrganizationDB: organizationDB,
 	}
 }
 
 // GetJWT godoc
 // @Summary Get a user JWT
-// @Description Get a user JWT
+// @Description Get a user JWT. The token is issued for one organization of the user, whose ID goes in the tid claim and scopes every product request to that organization. Members of several organizations must pick one with organization_id; users without organizations get a personal tenant, their own user ID, so they never share data with one another.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param request body dto.LoginInput true "user credentials"
 // @Success 200 {object} dto.GetJWTOutput
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /user/generate_token [post]
@@ -62,17 +73,37 @@ func (uh *UserHandler) GetJWT(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, tokenString, err := jwt.Encode(map[string]interface{}{
-		"sub": u.ID.String(),
-		"exp": time.Now().Add(time.Second * time.Duration(jwtExpiresIn)).Unix(),
-	})
+	organizations, err := uh.OrganizationDB.FindByUser(u.ID.String())
+	if err != nil {
+		fmt.Println("error to find organizations", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	organizationID, err := pickOrganization(organizations, user.OrganizationID)
+	if err != nil {
+		status := http.StatusForbidden
+		if errors.Is(err, errOrganizationRequired) {
+			status = http.StatusBadRequest
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	claims := map[string]interface{}{
+		"sub":  u.ID.String(),
+		"tid":  tenantOf(u, organizationID),
+		"role": u.Role,
+		"exp":  time.Now().Add(time.Second * time.Duration(jwtExpiresIn)).Unix(),
+	}
+	_, tokenString, err := jwt.Encode(claims)
 	if err != nil {
 		fmt.Println("error to generate token", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	accessToken := dto.GetJWTOutput{AccessToken: tokenString}
+	accessToken := dto.GetJWTOutput{AccessToken: tokenString, OrganizationID: organizationID}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(accessToken)
@@ -114,3 +145,34 @@ func (uh *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 
 }
+
+// tenantOf returns the tenant of a token issued to user for organizationID.
+// Users without organizations get a tenant of their own, their user ID, as
+// an empty tenant would be shared by all of them.
+func tenantOf(user *entity.User, organizationID *entityPKG.ID) string {
+	if organizationID == nil {
+		return user.ID.String()
+	}
+	return organizationID.String()
+}
+
+// pickOrganization returns the organization a token is issued for: the
+// requested one, which the user must be a member of, or their only one. It
+// returns nil for users without organizations.
+func pickOrganization(organizations []*entity.Organization, requested *entityPKG.ID) (*entityPKG.ID, error) {
+	if requested != nil {
+		for _, organization := range organizations {
+			if organization.ID == *requested {
+				return &organization.ID, nil
+			}
+		}
+		return nil, errNotMember
+	}
+	switch len(organizations) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &organizations[0].ID, nil
+	}
+	return nil, errOrganizationRequired
+}
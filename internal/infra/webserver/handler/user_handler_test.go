@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	"github.com/go-chi/jwtauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetJWTTenant(t *testing.T) {
+	db := newTestDB(t, &entity.User{}, &entity.Organization{}, &entity.Membership{})
+	userDB := database.NewUser(db)
+	organizationDB := database.NewOrganization(db)
+	handler := NewUserHandler(userDB, organizationDB)
+	jwt := jwtauth.New("HS256", []byte("secret"), nil)
+
+	tenantOf := func(email string) string {
+		r := httptest.NewRequest(http.MethodPost, "/user/generate_token", strings.NewReader(`{"email":"`+email+`","password":"123456"}`))
+		ctx := context.WithValue(r.Context(), "jwt", jwt)
+		ctx = context.WithValue(ctx, "jwtExpiresIn", 300)
+		w := httptest.NewRecorder()
+		handler.GetJWT(w, r.WithContext(ctx))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var output dto.GetJWTOutput
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&output))
+		token, err := jwt.Decode(output.AccessToken)
+		assert.NoError(t, err)
+		tid, _ := token.Get("tid")
+		return tid.(string)
+	}
+
+	alice, _ := entity.NewUser("Alice", "alice@example.com", "123456")
+	bob, _ := entity.NewUser("Bob", "bob@example.com", "123456")
+	carol, _ := entity.NewUser("Carol", "carol@example.com", "123456")
+	for _, user := range []*entity.User{alice, bob, carol} {
+		assert.NoError(t, userDB.Create(user))
+	}
+	brand, _ := entity.NewOrganization("Brand")
+	assert.NoError(t, organizationDB.Create(brand, carol.ID))
+
+	assert.Equal(t, alice.ID.String(), tenantOf("alice@example.com"))
+	assert.Equal(t, bob.ID.String(), tenantOf("bob@example.com"))
+	assert.Equal(t, brand.ID.String(), tenantOf("carol@example.com"))
+}
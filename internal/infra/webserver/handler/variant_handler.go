@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/FreitasGabriel/fullcycle-api/internal/dto"
+	"github.com/FreitasGabriel/fullcycle-api/internal/entity"
+	"github.com/FreitasGabriel/fullcycle-api/internal/infra/database"
+	entityPKG "github.com/FreitasGabriel/fullcycle-api/pkg/entity"
+	"github.com/go-chi/chi"
+	"gorm.io/gorm"
+)
+
+type VariantHandler struct {
+	VariantDB database.VariantInterface
+	ProductDB database.ProductInterface
+}
+
+func NewVariantHandler(variantDB database.VariantInterface, productDB database.ProductInterface) *VariantHandler {
+	return &VariantHandler{
+		VariantDB: variantDB,
+		ProductDB: productDB,
+	}
+}
+
+// Create Variant godoc
+// @Summary Create product variant
+// @Description Create a variant of a product with its own SKU, options and optional price override in the currency of the product price. Only the owner of the product or an admin can do it.
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param request body dto.VariantInput true "variant request"
+// @Success 201 {object} entity.Variant
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/variants [post]
+// @Security ApiKeyAuth
+func (vh *VariantHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	productID, err := entityPKG.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !checkProductOwnerByID(w, r, vh.ProductDB, productID.String()) {
+		return
+	}
+
+	var input dto.VariantInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	variant, err := entity.NewVariant(productID, input.SKU, input.Price, input.Options)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := vh.VariantDB.Create(variant); err != nil {
+		handleVariantWriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(variant)
+}
+
+// List Variants godoc
+// @Summary List product variants
+// @Description List the variants of a product
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Success 200 {array} entity.Variant
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/variants [get]
+// @Security ApiKeyAuth
+func (vh *VariantHandler) GetVariants(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	variants, err := vh.VariantDB.FindByProduct(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(variants)
+}
+
+// Get Variant godoc
+// @Summary Get a product variant
+// @Description Get a product variant
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param variantID path string true "variant ID" Format(uuid)
+// @Success 200 {object} entity.Variant
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /products/{id}/variants/{variantID} [get]
+// @Security ApiKeyAuth
+func (vh *VariantHandler) GetVariant(w http.ResponseWriter, r *http.Request) {
+	id, variantID, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	variant, err := vh.VariantDB.FindById(id, variantID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(variant)
+}
+
+// Update Variant godoc
+// @Summary Update a product variant
+// @Description Replace the SKU, options and price override of a variant. The price override must be in the currency of the product price. Only the owner of the product or an admin can do it.
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param variantID path string true "variant ID" Format(uuid)
+// @Param request body dto.VariantInput true "variant request"
+// @Success 200 {object} entity.Variant
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/variants/{variantID} [put]
+// @Security ApiKeyAuth
+func (vh *VariantHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	id, variantID, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+	if !checkProductOwnerByID(w, r, vh.ProductDB, id) {
+		return
+	}
+
+	var input dto.VariantInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	variant, err := vh.VariantDB.FindById(id, variantID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err := variant.Set(input.SKU, input.Price, input.Options); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	if err := vh.VariantDB.Update(variant); err != nil {
+		handleVariantWriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(variant)
+}
+
+// Delete Variant godoc
+// @Summary Delete a product variant
+// @Description Delete a product variant. Only the owner of the product or an admin can do it.
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "product ID" Format(uuid)
+// @Param variantID path string true "variant ID" Format(uuid)
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /products/{id}/variants/{variantID} [delete]
+// @Security ApiKeyAuth
+func (vh *VariantHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	id, variantID, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+	if !checkProductOwnerByID(w, r, vh.ProductDB, id) {
+		return
+	}
+
+	if err := vh.VariantDB.Delete(id, variantID); err != nil {
+		handleVariantWriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// variantParams reads the product and variant ids from the path, writing a
+// 400 response and returning false when either is not a valid id.
+func variantParams(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	id := chi.URLParam(r, "id")
+	variantID := chi.URLParam(r, "variantID")
+	if _, err := entityPKG.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return "", "", false
+	}
+	if _, err := entityPKG.ParseID(variantID); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return "", "", false
+	}
+	return id, variantID, true
+}
+
+func handleVariantWriteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, entity.ErrVariantCurrency):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	case errors.Is(err, database.ErrDuplicateSKU),
+		errors.Is(err, database.ErrDuplicateVariantOptions):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{Message: err.Error()})
+	default:
+		fmt.Println("error to write variant", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,168 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrInvalidCurrency  = errors.New("invalid currency")
+	ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+	ErrAmountOverflow   = errors.New("amount is out of range")
+)
+
+// DefaultCurrency is assumed where a price is given without a currency.
+const DefaultCurrency = "BRL"
+
+// currencyExponents holds the number of decimal places of the minor unit of
+// each supported ISO 4217 currency.
+var currencyExponents = map[string]int{
+	"BRL": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"JPY": 0,
+	"USD": 2,
+}
+
+// Money is an exact amount of a currency, kept as an integer number of minor
+// units (cents for BRL). In JSON the amount is a decimal string so it never
+// goes through a float.
+type Money struct {
+	Amount   int64  `json:"amount" gorm:"column:amount" swaggertype:"string" example:"10.50"`
+	Currency string `json:"currency" gorm:"column:currency;size:3" example:"BRL"`
+}
+
+func NewMoney(amount int64, currency string) (Money, error) {
+	if !IsCurrency(currency) {
+		return Money{}, ErrInvalidCurrency
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// ParseMoney parses a decimal amount such as "10.50" in currency. More
+// decimal places than the currency has are rejected rather than rounded.
+func ParseMoney(amount, currency string) (Money, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return Money{}, ErrInvalidCurrency
+	}
+
+	negative := strings.HasPrefix(amount, "-")
+	whole, fraction, _ := strings.Cut(strings.TrimPrefix(amount, "-"), ".")
+	if whole == "" || len(fraction) > exponent || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, ErrInvalidAmount
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	units, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidAmount
+	}
+	if negative {
+		units = -units
+	}
+	return Money{Amount: units, Currency: currency}, nil
+}
+
+func IsCurrency(code string) bool {
+	_, ok := currencyExponents[code]
+	return ok
+}
+
+// CurrencyExponent returns the number of decimal places of currency.
+func CurrencyExponent(currency string) (int, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return 0, ErrInvalidCurrency
+	}
+	return exponent, nil
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns the sum of m and other, which must be in the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Times returns m multiplied by n, such as the total of n units priced m.
+func (m Money) Times(n int64) (Money, error) {
+	amount := m.Amount * n
+	if n != 0 && (amount/n != m.Amount || (n == -1 && m.Amount == math.MinInt64)) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+// String formats the amount as a decimal string, without the currency.
+func (m Money) String() string {
+	exponent := currencyExponents[m.Currency]
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	cut := len(digits) - exponent
+	return sign + digits[:cut] + "." + digits[cut:]
+}
+
+type moneyJSON struct {
+	Amount   json.Number `json:"amount"`
+	Currency string      `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.String(), m.Currency})
+}
+
+// UnmarshalJSON accepts the amount either as a decimal string or as a JSON
+// number, which is read from its literal text and never as a float.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	money, err := ParseMoney(raw.Amount.String(), raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
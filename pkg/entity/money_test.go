@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	m, err := ParseMoney("10.5", "BRL")
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Amount: 1050, Currency: "BRL"}, m)
+	assert.Equal(t, "10.50", m.String())
+
+	m, err = ParseMoney("-0.07", "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-7), m.Amount)
+	assert.Equal(t, "-0.07", m.String())
+
+	m, err = ParseMoney("1500", "JPY")
+	assert.Nil(t, err)
+	assert.Equal(t, "1500", m.String())
+
+	_, err = ParseMoney("10.505", "BRL")
+	assert.Equal(t, ErrInvalidAmount, err)
+
+	_, err = ParseMoney("1e3", "BRL")
+	assert.Equal(t, ErrInvalidAmount, err)
+
+	_, err = ParseMoney("10", "XYZ")
+	assert.Equal(t, ErrInvalidCurrency, err)
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var m Money
+	err := json.Unmarshal([]byte(`{"amount":"0.30","currency":"BRL"}`), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Amount: 30, Currency: "BRL"}, m)
+
+	err = json.Unmarshal([]byte(`{"amount":0.1,"currency":"USD"}`), &m)
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Amount: 10, Currency: "USD"}, m)
+
+	raw, err := json.Marshal(Money{Amount: 5, Currency: "EUR"})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"amount":"0.05","currency":"EUR"}`, string(raw))
+
+	err = json.Unmarshal([]byte(`{"amount":"abc","currency":"USD"}`), &m)
+	assert.NotNil(t, err)
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	sum, err := Money{Amount: 1050, Currency: "BRL"}.Add(Money{Amount: 25, Currency: "BRL"})
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Amount: 1075, Currency: "BRL"}, sum)
+
+	_, err = Money{Amount: 1050, Currency: "BRL"}.Add(Money{Amount: 25, Currency: "USD"})
+	assert.Equal(t, ErrCurrencyMismatch, err)
+
+	_, err = Money{Amount: math.MaxInt64, Currency: "BRL"}.Add(Money{Amount: 1, Currency: "BRL"})
+	assert.Equal(t, ErrAmountOverflow, err)
+
+	total, err := Money{Amount: 1050, Currency: "BRL"}.Times(3)
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Amount: 3150, Currency: "BRL"}, total)
+
+	_, err = Money{Amount: math.MaxInt64 / 2, Currency: "BRL"}.Times(3)
+	assert.Equal(t, ErrAmountOverflow, err)
+}
@@ -0,0 +1,56 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	doc := `{"name":"product","price":10,"tags":{"a":1,"b":2}}`
+	patched, err := MergePatch([]byte(doc), []byte(`{"price":12.5,"tags":{"a":null,"c":3}}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"product","price":12.5,"tags":{"b":2,"c":3}}`, string(patched))
+
+	_, err = MergePatch([]byte(doc), []byte(`{`))
+	assert.Equal(t, ErrInvalidPatch, err)
+}
+
+func TestApply(t *testing.T) {
+	doc := `{"name":"product","price":10,"list":[1,2,3],"a~b":{"c/d":true}}`
+	patch := `[
+		{"op":"test","path":"/name","value":"product"},
+		{"op":"replace","path":"/price","value":20},
+		{"op":"add","path":"/list/1","value":9},
+		{"op":"add","path":"/list/-","value":4},
+		{"op":"remove","path":"/list/0"},
+		{"op":"copy","from":"/name","path":"/alias"},
+		{"op":"move","from":"/a~0b/c~1d","path":"/flag"}
+	]`
+
+	patched, err := Apply([]byte(doc), []byte(patch))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"product","alias":"product","price":20,"list":[9,2,3,4],"a~b":{},"flag":true}`, string(patched))
+}
+
+func TestApplyFailures(t *testing.T) {
+	doc := []byte(`{"name":"product","list":[1]}`)
+
+	_, err := Apply(doc, []byte(`[{"op":"test","path":"/name","value":"other"}]`))
+	assert.ErrorIs(t, err, ErrTestFailed)
+
+	_, err = Apply(doc, []byte(`[{"op":"replace","path":"/missing","value":1}]`))
+	assert.ErrorIs(t, err, ErrPathNotFound)
+
+	_, err = Apply(doc, []byte(`[{"op":"add","path":"/list/5","value":1}]`))
+	assert.ErrorIs(t, err, ErrPathNotFound)
+
+	_, err = Apply(doc, []byte(`[{"op":"add","path":"/name"}]`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+
+	_, err = Apply(doc, []byte(`[{"op":"unknown","path":"/name"}]`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+
+	_, err = Apply(doc, []byte(`{"op":"remove","path":"/name"}`))
+	assert.Equal(t, ErrInvalidPatch, err)
+}
@@ -0,0 +1,58 @@
+// Package thumbnail scales images down with the standard library only.
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+)
+
+// Fit scales src down to fit in a size x size box, keeping its aspect ratio.
+// Each pixel of the result is the average of the source pixels it covers,
+// which avoids the aliasing of nearest neighbour sampling. Images that
+// already fit are returned unchanged.
+func Fit(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if size < 1 || (srcW <= size && srcH <= size) {
+		return src
+	}
+
+	dstW, dstH := size, size
+	if srcW > srcH {
+		dstH = max(1, srcH*size/srcW)
+	} else {
+		dstW = max(1, srcW*size/srcH)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0 := bounds.Min.Y + y*srcH/dstH
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*srcH/dstH)
+		for x := 0; x < dstW; x++ {
+			x0 := bounds.Min.X + x*srcW/dstW
+			x1 := max(x0+1, bounds.Min.X+(x+1)*srcW/dstW)
+			dst.SetRGBA(x, y, average(src, x0, y0, x1, y1))
+		}
+	}
+	return dst
+}
+
+func average(src image.Image, x0, y0, x1, y1 int) color.RGBA {
+	var r, g, b, a uint64
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			cr, cg, cb, ca := src.At(x, y).RGBA()
+			r += uint64(cr)
+			g += uint64(cg)
+			b += uint64(cb)
+			a += uint64(ca)
+		}
+	}
+	n := uint64((x1 - x0) * (y1 - y0))
+	return color.RGBA{
+		R: uint8(r / n >> 8),
+		G: uint8(g / n >> 8),
+		B: uint8(b / n >> 8),
+		A: uint8(a / n >> 8),
+	}
+}
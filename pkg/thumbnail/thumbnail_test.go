@@ -0,0 +1,42 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFitKeepsAspectRatio(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	dst := Fit(src, 100)
+	assert.Equal(t, image.Rect(0, 0, 100, 50), dst.Bounds())
+
+	src = image.NewRGBA(image.Rect(0, 0, 30, 300))
+	dst = Fit(src, 100)
+	assert.Equal(t, image.Rect(0, 0, 10, 100), dst.Bounds())
+}
+
+func TestFitDoesNotUpscale(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 50, 20))
+	assert.Same(t, src, Fit(src, 100))
+}
+
+func TestFitAveragesPixels(t *testing.T) {
+	// Alternating black and white columns average out to grey.
+	src := image.NewRGBA(image.Rect(10, 10, 14, 12))
+	for y := 10; y < 12; y++ {
+		for x := 10; x < 14; x++ {
+			if x%2 == 0 {
+				src.Set(x, y, color.White)
+			} else {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	dst := Fit(src, 2).(*image.RGBA)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), dst.Bounds())
+	assert.Equal(t, color.RGBA{R: 127, G: 127, B: 127, A: 255}, dst.RGBAAt(0, 0))
+}
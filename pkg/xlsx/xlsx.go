@@ -0,0 +1,149 @@
+// Package xlsx streams single sheet Office Open XML workbooks with the
+// standard library only.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+var ErrClosed = errors.New("xlsx: writer is closed")
+
+// Cell is a value of a row. Numeric cells holding a plain decimal number
+// are stored as numbers; every other cell is stored as text.
+type Cell struct {
+	Value   string
+	Numeric bool
+}
+
+func Text(value string) Cell {
+	return Cell{Value: value}
+}
+
+func Number(value string) Cell {
+	return Cell{Value: value, Numeric: true}
+}
+
+// Writer writes a workbook with one sheet, row by row. Rows are written
+// straight to the underlying writer, so memory use does not grow with the
+// number of rows.
+type Writer struct {
+	zip    *zip.Writer
+	sheet  *bufio.Writer
+	closed bool
+}
+
+var staticParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// NewWriter starts a workbook whose only sheet is called sheetName.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	archive := zip.NewWriter(w)
+	for _, part := range staticParts {
+		if err := writePart(archive, part.name, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	workbook := xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + escape(sheetName) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	if err := writePart(archive, "xl/workbook.xml", workbook); err != nil {
+		return nil, err
+	}
+
+	part, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(part)
+	sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return &Writer{zip: archive, sheet: sheet}, nil
+}
+
+func (w *Writer) WriteRow(cells ...Cell) error {
+	if w.closed {
+		return ErrClosed
+	}
+	w.sheet.WriteString("<row>")
+	for _, cell := range cells {
+		if cell.Numeric && isDecimal(cell.Value) {
+			w.sheet.WriteString(`<c t="n"><v>` + cell.Value + `</v></c>`)
+			continue
+		}
+		w.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">` + escape(cell.Value) + `</t></is></c>`)
+	}
+	_, err := w.sheet.WriteString("</row>")
+	return err
+}
+
+// Flush sends the rows written so far to the underlying writer.
+func (w *Writer) Flush() error {
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zip.Flush()
+}
+
+// Close ends the sheet and the archive. It does not close the underlying
+// writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+	w.closed = true
+	w.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zip.Close()
+}
+
+func writePart(archive *zip.Writer, name, content string) error {
+	part, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(part, content)
+	return err
+}
+
+// escape escapes text for XML, replacing characters XML cannot hold.
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// isDecimal reports whether s is a plain decimal number such as -12.50.
+func isDecimal(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	whole, fraction, hasPoint := strings.Cut(s, ".")
+	if whole == "" || (hasPoint && fraction == "") {
+		return false
+	}
+	for _, r := range whole + fraction {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
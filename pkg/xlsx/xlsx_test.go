@@ -0,0 +1,58 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readPart(t *testing.T, archive *zip.Reader, name string) string {
+	for _, file := range archive.File {
+		if file.Name == name {
+			r, err := file.Open()
+			assert.NoError(t, err)
+			defer r.Close()
+			content, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			return string(content)
+		}
+	}
+	t.Fatalf("missing part %s", name)
+	return ""
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Products & more")
+	assert.NoError(t, err)
+	assert.NoError(t, w.WriteRow(Text("name"), Text("price")))
+	assert.NoError(t, w.WriteRow(Text("<Shirt>"), Number("-12.50")))
+	assert.NoError(t, w.WriteRow(Text("Hat"), Number("NaN")))
+	assert.NoError(t, w.Close())
+	assert.Equal(t, ErrClosed, w.WriteRow(Text("late")))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	assert.Contains(t, readPart(t, archive, "[Content_Types].xml"), "/xl/worksheets/sheet1.xml")
+	assert.Contains(t, readPart(t, archive, "_rels/.rels"), "xl/workbook.xml")
+	assert.Contains(t, readPart(t, archive, "xl/_rels/workbook.xml.rels"), "worksheets/sheet1.xml")
+	assert.Contains(t, readPart(t, archive, "xl/workbook.xml"), `name="Products &amp; more"`)
+
+	sheet := readPart(t, archive, "xl/worksheets/sheet1.xml")
+	assert.Contains(t, sheet, `<t xml:space="preserve">&lt;Shirt&gt;</t>`)
+	assert.Contains(t, sheet, `<c t="n"><v>-12.50</v></c>`)
+	assert.Contains(t, sheet, `<t xml:space="preserve">NaN</t>`)
+	assert.Contains(t, sheet, `</sheetData></worksheet>`)
+}
+
+func TestIsDecimal(t *testing.T) {
+	for _, s := range []string{"0", "12", "-3.25", "100.00"} {
+		assert.True(t, isDecimal(s), s)
+	}
+	for _, s := range []string{"", "-", "1.", ".5", "1e5", "Inf", "1.2.3"} {
+		assert.False(t, isDecimal(s), s)
+	}
+}